@@ -2,6 +2,7 @@ package cte
 
 import (
 	"reflect"
+	"time"
 )
 
 var (
@@ -18,6 +19,7 @@ type analyzedPlan struct {
 	pType        reflect.Type
 	isMasterPlan bool
 	isSequential bool
+	budget       time.Duration
 	components   []parsedComponent
 	loaders      []loadFn
 	preHooks     []preHook
@@ -99,10 +101,16 @@ func (pa *planAnalyzer) analyze() analyzedPlan {
 
 	loaders := pa.itself.extractLoaders()
 
+	var budget time.Duration
+	if bp, ok := pa.plan.(BudgetedPlan); ok {
+		budget = bp.CTEBudget()
+	}
+
 	return analyzedPlan{
 		pType:        extractUnderlyingType(pa.planValue),
 		isMasterPlan: isMasterPlan,
 		isSequential: pa.plan.IsSequentialCTEPlan(),
+		budget:       budget,
 		components:   pa.components,
 		loaders:      loaders,
 		preHooks:     pa.preHooks,
@@ -0,0 +1,170 @@
+package cte
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+const defaultBatchWindow = time.Millisecond
+
+// BatchFn loads the data for all given keys in one go. It must return exactly one
+// LoadingData per key, at the same index as the key. A non-nil error fails the load
+// of every key in the batch.
+type BatchFn func(ctx context.Context, keys []interface{}) ([]LoadingData, error)
+
+// BatchLoader combines loads issued within a short window, whether by many concurrent
+// executions or by several components in the same plan, into a single batch call.
+// It is meant to be called from the Load method of a LoadingComputer.
+type BatchLoader interface {
+	// Load adds the given key to the pending batch and blocks until the batch has been
+	// processed or the given context is done, whichever comes first.
+	Load(ctx context.Context, key interface{}) (interface{}, error)
+}
+
+type batchLoaderConfigs struct {
+	window       time.Duration
+	maxBatchSize int
+}
+
+type BatchLoaderOption func(*batchLoaderConfigs)
+
+// WithBatchWindow sets how long BatchLoader waits, after receiving the first key of a
+// batch, for more keys to arrive before processing the batch. Defaults to 1ms.
+func WithBatchWindow(window time.Duration) BatchLoaderOption {
+	return func(configs *batchLoaderConfigs) {
+		configs.window = window
+	}
+}
+
+// WithMaxBatchSize sets the number of keys at which BatchLoader processes the pending
+// batch right away without waiting for the batch window to elapse. If `maxBatchSize <= 0`,
+// batches are processed based on the batch window only.
+func WithMaxBatchSize(maxBatchSize int) BatchLoaderOption {
+	return func(configs *batchLoaderConfigs) {
+		configs.maxBatchSize = maxBatchSize
+	}
+}
+
+type pendingBatch struct {
+	keys    []interface{}
+	results []LoadingData
+	done    chan struct{}
+	timer   *time.Timer
+}
+
+type batchLoader struct {
+	mu      sync.Mutex
+	configs *batchLoaderConfigs
+	batchFn BatchFn
+	pending *pendingBatch
+}
+
+// NewBatchLoader returns a new BatchLoader which processes batches using the given BatchFn.
+//
+// Note: Batches combine keys coming from different executions. Hence, BatchFn is invoked
+// with a background context that carries none of the values or deadlines of the callers.
+func NewBatchLoader(batchFn BatchFn, options ...BatchLoaderOption) BatchLoader {
+	configs := &batchLoaderConfigs{
+		window: defaultBatchWindow,
+	}
+
+	for _, o := range options {
+		o(configs)
+	}
+
+	return &batchLoader{
+		configs: configs,
+		batchFn: batchFn,
+	}
+}
+
+func (bl *batchLoader) Load(ctx context.Context, key interface{}) (interface{}, error) {
+	batch, idx := bl.enqueue(key)
+
+	select {
+	case <-batch.done:
+		result := batch.results[idx]
+		return result.Data, result.Err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (bl *batchLoader) enqueue(key interface{}) (*pendingBatch, int) {
+	bl.mu.Lock()
+	defer bl.mu.Unlock()
+
+	batch := bl.pending
+	if batch == nil {
+		batch = &pendingBatch{
+			done: make(chan struct{}),
+		}
+
+		batch.timer = time.AfterFunc(
+			bl.configs.window, func() {
+				bl.dispatch(batch)
+			},
+		)
+
+		bl.pending = batch
+	}
+
+	idx := len(batch.keys)
+	batch.keys = append(batch.keys, key)
+
+	if bl.configs.maxBatchSize > 0 && len(batch.keys) >= bl.configs.maxBatchSize {
+		batch.timer.Stop()
+		bl.pending = nil
+
+		go bl.process(batch)
+	}
+
+	return batch, idx
+}
+
+func (bl *batchLoader) dispatch(batch *pendingBatch) {
+	bl.mu.Lock()
+
+	// The batch might have been dispatched already after reaching max size
+	if bl.pending != batch {
+		bl.mu.Unlock()
+		return
+	}
+
+	bl.pending = nil
+	bl.mu.Unlock()
+
+	bl.process(batch)
+}
+
+func (bl *batchLoader) process(batch *pendingBatch) {
+	defer close(batch.done)
+
+	results, err := func() (results []LoadingData, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("panic executing batch load: %v \n %s", r, debug.Stack())
+			}
+		}()
+
+		return bl.batchFn(context.Background(), batch.keys)
+	}()
+
+	if err == nil && len(results) != len(batch.keys) {
+		err = ErrBatchSizeMismatch.Err(len(batch.keys), len(results))
+	}
+
+	if err != nil {
+		results = make([]LoadingData, len(batch.keys))
+		for i := range results {
+			results[i] = LoadingData{
+				Err: err,
+			}
+		}
+	}
+
+	batch.results = results
+}
@@ -0,0 +1,163 @@
+package cte
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBatchLoader_Load(t *testing.T) {
+	scenarios := []struct {
+		desc string
+		test func(t *testing.T)
+	}{
+		{
+			desc: "loads within the window are combined",
+			test: func(t *testing.T) {
+				var mu sync.Mutex
+				var batches [][]interface{}
+
+				bl := NewBatchLoader(
+					func(ctx context.Context, keys []interface{}) ([]LoadingData, error) {
+						mu.Lock()
+						batches = append(batches, keys)
+						mu.Unlock()
+
+						results := make([]LoadingData, 0, len(keys))
+						for _, key := range keys {
+							results = append(results, LoadingData{Data: key.(int) * 10})
+						}
+
+						return results, nil
+					},
+					WithBatchWindow(20*time.Millisecond),
+				)
+
+				var wg sync.WaitGroup
+				results := make([]interface{}, 5)
+				for i := 0; i < 5; i++ {
+					idx := i
+
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+
+						results[idx], _ = bl.Load(context.Background(), idx)
+					}()
+				}
+
+				wg.Wait()
+
+				assert.Equal(t, 1, len(batches))
+				assert.Equal(t, 5, len(batches[0]))
+				for i, r := range results {
+					assert.Equal(t, i*10, r)
+				}
+			},
+		},
+		{
+			desc: "batch is processed once max size is reached",
+			test: func(t *testing.T) {
+				batchSizes := make(chan int, 2)
+
+				bl := NewBatchLoader(
+					func(ctx context.Context, keys []interface{}) ([]LoadingData, error) {
+						batchSizes <- len(keys)
+						return make([]LoadingData, len(keys)), nil
+					},
+					WithBatchWindow(time.Hour),
+					WithMaxBatchSize(2),
+				)
+
+				var wg sync.WaitGroup
+				for i := 0; i < 2; i++ {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+
+						_, err := bl.Load(context.Background(), 1)
+						assert.Nil(t, err)
+					}()
+				}
+
+				wg.Wait()
+
+				assert.Equal(t, 2, <-batchSizes)
+			},
+		},
+		{
+			desc: "per-key and batch errors",
+			test: func(t *testing.T) {
+				bl := NewBatchLoader(
+					func(ctx context.Context, keys []interface{}) ([]LoadingData, error) {
+						return []LoadingData{{Err: assert.AnError}}, nil
+					},
+				)
+
+				_, err := bl.Load(context.Background(), 1)
+				assert.Equal(t, assert.AnError, err)
+
+				bl = NewBatchLoader(
+					func(ctx context.Context, keys []interface{}) ([]LoadingData, error) {
+						return nil, assert.AnError
+					},
+				)
+
+				_, err = bl.Load(context.Background(), 1)
+				assert.Equal(t, assert.AnError, err)
+			},
+		},
+		{
+			desc: "mismatched result size",
+			test: func(t *testing.T) {
+				bl := NewBatchLoader(
+					func(ctx context.Context, keys []interface{}) ([]LoadingData, error) {
+						return nil, nil
+					},
+				)
+
+				_, err := bl.Load(context.Background(), 1)
+				assert.Equal(t, ErrBatchSizeMismatch.Err(1, 0), err)
+			},
+		},
+		{
+			desc: "panic is converted into error",
+			test: func(t *testing.T) {
+				bl := NewBatchLoader(
+					func(ctx context.Context, keys []interface{}) ([]LoadingData, error) {
+						panic("something went wrong")
+					},
+				)
+
+				_, err := bl.Load(context.Background(), 1)
+				assert.Contains(t, err.Error(), "something went wrong")
+			},
+		},
+		{
+			desc: "caller respects its own context",
+			test: func(t *testing.T) {
+				bl := NewBatchLoader(
+					func(ctx context.Context, keys []interface{}) ([]LoadingData, error) {
+						return make([]LoadingData, len(keys)), nil
+					},
+					WithBatchWindow(time.Hour),
+				)
+
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+
+				_, err := bl.Load(ctx, 1)
+				assert.Equal(t, context.Canceled, err)
+			},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		s := scenario
+
+		t.Run(s.desc, s.test)
+	}
+}
@@ -0,0 +1,181 @@
+package cte
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// CachePolicy can be declared under the `cache` key in CTEMetadata to let the engine cache
+// the outcome of a computer in the CacheStore configured via WithCacheStore. Outcomes are
+// cached only if the computer returns a nil error. Outcomes of SwitchComputer are never
+// cached. SideEffectComputer and SideEffectComputerWithLoadingData cannot declare a policy
+// since serving their outcome from the cache would skip their side effects.
+type CachePolicy interface {
+	// CacheKey returns the key to cache the outcome under for the given plan. Returning
+	// an empty key skips the cache entirely for this particular execution.
+	CacheKey(p MasterPlan) string
+	// CacheTTL returns how long a cached outcome remains valid.
+	CacheTTL() time.Duration
+}
+
+// CacheStore stores outcomes of computers. Implementations must be safe for concurrent use.
+type CacheStore interface {
+	// Get returns the value cached under the given key if it has not expired yet.
+	Get(key string) (interface{}, bool)
+	// Set caches the given value under the given key for the given duration.
+	Set(key string, value interface{}, ttl time.Duration)
+}
+
+type cacheEntry struct {
+	key       string
+	value     interface{}
+	expiresAt time.Time
+}
+
+func (ce cacheEntry) isExpired() bool {
+	return time.Now().After(ce.expiresAt)
+}
+
+type lruCacheStore struct {
+	mu       sync.Mutex
+	capacity int
+	entries  map[string]*list.Element
+	order    *list.List // Most recently used entries at the front
+}
+
+// NewLRUCacheStore returns an in-memory CacheStore which evicts the least recently used
+// entry once the given capacity is reached.
+func NewLRUCacheStore(capacity int) CacheStore {
+	return &lruCacheStore{
+		capacity: capacity,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+func (s *lruCacheStore) Get(key string) (interface{}, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	elem, ok := s.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	entry := elem.Value.(cacheEntry)
+	if entry.isExpired() {
+		s.remove(elem)
+		return nil, false
+	}
+
+	s.order.MoveToFront(elem)
+
+	return entry.value, true
+}
+
+func (s *lruCacheStore) Set(key string, value interface{}, ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry := cacheEntry{
+		key:       key,
+		value:     value,
+		expiresAt: time.Now().Add(ttl),
+	}
+
+	if elem, ok := s.entries[key]; ok {
+		elem.Value = entry
+		s.order.MoveToFront(elem)
+		return
+	}
+
+	s.entries[key] = s.order.PushFront(entry)
+
+	for s.capacity > 0 && s.order.Len() > s.capacity {
+		s.remove(s.order.Back())
+	}
+}
+
+func (s *lruCacheStore) remove(elem *list.Element) {
+	s.order.Remove(elem)
+	delete(s.entries, elem.Value.(cacheEntry).key)
+}
+
+type fileCacheStore struct {
+	mu  sync.RWMutex
+	dir string
+}
+
+type fileCacheEntry struct {
+	Value     interface{}
+	ExpiresAt time.Time
+}
+
+// NewFileCacheStore returns a CacheStore which persists every entry as a file in the given
+// directory so that cached outcomes survive restarts. Values are encoded using encoding/gob
+// and hence, their concrete types must be registered via gob.Register. Errors when reading
+// or writing files are treated as cache misses.
+func NewFileCacheStore(dir string) CacheStore {
+	return &fileCacheStore{
+		dir: dir,
+	}
+}
+
+func (s *fileCacheStore) Get(key string) (interface{}, bool) {
+	s.mu.RLock()
+	content, err := os.ReadFile(s.path(key))
+	s.mu.RUnlock()
+
+	if err != nil {
+		return nil, false
+	}
+
+	var entry fileCacheEntry
+	if err := gob.NewDecoder(bytes.NewReader(content)).Decode(&entry); err != nil {
+		return nil, false
+	}
+
+	if time.Now().After(entry.ExpiresAt) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		_ = os.Remove(s.path(key))
+		return nil, false
+	}
+
+	return entry.Value, true
+}
+
+func (s *fileCacheStore) Set(key string, value interface{}, ttl time.Duration) {
+	var buf bytes.Buffer
+
+	entry := fileCacheEntry{
+		Value:     value,
+		ExpiresAt: time.Now().Add(ttl),
+	}
+
+	if err := gob.NewEncoder(&buf).Encode(&entry); err != nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return
+	}
+
+	_ = os.WriteFile(s.path(key), buf.Bytes(), 0o644)
+}
+
+func (s *fileCacheStore) path(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return filepath.Join(s.dir, hex.EncodeToString(hash[:]))
+}
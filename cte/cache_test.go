@@ -0,0 +1,115 @@
+package cte
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRUCacheStore(t *testing.T) {
+	scenarios := []struct {
+		desc string
+		test func(t *testing.T)
+	}{
+		{
+			desc: "get after set",
+			test: func(t *testing.T) {
+				s := NewLRUCacheStore(2)
+
+				_, ok := s.Get("key")
+				assert.False(t, ok)
+
+				s.Set("key", 1, time.Minute)
+
+				value, ok := s.Get("key")
+				assert.Equal(t, 1, value)
+				assert.True(t, ok)
+
+				s.Set("key", 2, time.Minute)
+
+				value, ok = s.Get("key")
+				assert.Equal(t, 2, value)
+				assert.True(t, ok)
+			},
+		},
+		{
+			desc: "least recently used entry is evicted",
+			test: func(t *testing.T) {
+				s := NewLRUCacheStore(2)
+
+				s.Set("key1", 1, time.Minute)
+				s.Set("key2", 2, time.Minute)
+
+				// key1 becomes the most recently used
+				s.Get("key1")
+
+				s.Set("key3", 3, time.Minute)
+
+				_, ok := s.Get("key2")
+				assert.False(t, ok)
+
+				value, ok := s.Get("key1")
+				assert.Equal(t, 1, value)
+				assert.True(t, ok)
+
+				value, ok = s.Get("key3")
+				assert.Equal(t, 3, value)
+				assert.True(t, ok)
+			},
+		},
+		{
+			desc: "expired entry is removed",
+			test: func(t *testing.T) {
+				s := NewLRUCacheStore(2)
+
+				s.Set("key", 1, -time.Second)
+
+				_, ok := s.Get("key")
+				assert.False(t, ok)
+				assert.Equal(t, 0, len(s.(*lruCacheStore).entries))
+			},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		s := scenario
+
+		t.Run(s.desc, s.test)
+	}
+}
+
+func TestFileCacheStore(t *testing.T) {
+	type route struct {
+		Distance float64
+	}
+
+	dir := t.TempDir()
+
+	s := NewFileCacheStore(dir)
+
+	_, ok := s.Get("key")
+	assert.False(t, ok)
+
+	s.Set("key", 1.5, time.Minute)
+
+	value, ok := s.Get("key")
+	assert.Equal(t, 1.5, value)
+	assert.True(t, ok)
+
+	// Entries survive across stores pointing to the same directory
+	value, ok = NewFileCacheStore(dir).Get("key")
+	assert.Equal(t, 1.5, value)
+	assert.True(t, ok)
+
+	// Unregistered types cannot be encoded and hence, are never cached
+	s.Set("unregistered", route{Distance: 1}, time.Minute)
+
+	_, ok = s.Get("unregistered")
+	assert.False(t, ok)
+
+	s.Set("expired", 1.5, -time.Second)
+
+	_, ok = s.Get("expired")
+	assert.False(t, ok)
+}
@@ -0,0 +1,60 @@
+package cte
+
+import (
+	"context"
+)
+
+// ComponentCompletion describes the outcome of a component the moment it completes.
+type ComponentCompletion struct {
+	ID string
+	// Value is the outcome of the component. For switch components, it is the executed plan
+	// or the value the plan ended early with.
+	Value interface{}
+	Err   error
+}
+
+// ContextWithCompletionHandler returns a new context which makes Engine invoke the given handler
+// every time a component of an execution using it completes, before the whole plan completes.
+// The handler is invoked synchronously on the goroutine of the component, concurrently for
+// components executing in parallel, and hence, should return quickly.
+func ContextWithCompletionHandler(ctx context.Context, handler func(ComponentCompletion)) context.Context {
+	return withComponentObserver(
+		ctx, completionSubscriber(
+			func(ctx context.Context, c ComponentCompletion) {
+				handler(c)
+			},
+		),
+	)
+}
+
+// ContextWithCompletionChannel returns a new context which makes Engine send the outcome of every
+// component of an execution using it to the given channel as soon as the component completes.
+// Sending blocks the component, so the channel must be buffered or drained concurrently.
+// Once the context of the execution is done, outcomes that cannot be sent right away are
+// dropped. The channel is not closed by Engine, wait for the execution to complete instead.
+func ContextWithCompletionChannel(ctx context.Context, ch chan<- ComponentCompletion) context.Context {
+	return withComponentObserver(
+		ctx, completionSubscriber(
+			func(ctx context.Context, c ComponentCompletion) {
+				select {
+				case ch <- c:
+				case <-ctx.Done():
+				}
+			},
+		),
+	)
+}
+
+type completionSubscriber func(context.Context, ComponentCompletion)
+
+func (s completionSubscriber) componentStarted(ctx context.Context, componentID string) {}
+
+func (s completionSubscriber) componentCompleted(ctx context.Context, componentID string, value interface{}, err error) {
+	s(
+		ctx, ComponentCompletion{
+			ID:    componentID,
+			Value: value,
+			Err:   err,
+		},
+	)
+}
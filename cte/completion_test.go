@@ -0,0 +1,182 @@
+package cte
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type completionTest_FirstComputer struct{}
+
+func (completionTest_FirstComputer) Compute(ctx context.Context, p MasterPlan) (interface{}, error) {
+	return 1, nil
+}
+
+type completionTest_First SyncResult
+
+func (completionTest_First) CTEMetadata() interface{} {
+	return struct {
+		computer completionTest_FirstComputer
+	}{}
+}
+
+type completionTest_SecondComputer struct{}
+
+func (completionTest_SecondComputer) Compute(ctx context.Context, p MasterPlan) (interface{}, error) {
+	casted := p.(*completionTest_Plan)
+	casted.receivedBeforeSecond = casted.received()
+
+	return nil, casted.secondErr
+}
+
+type completionTest_Second SyncResult
+
+func (completionTest_Second) CTEMetadata() interface{} {
+	return struct {
+		computer completionTest_SecondComputer
+	}{}
+}
+
+type completionTest_Plan struct {
+	engine               Engine
+	secondErr            error
+	received             func() []ComponentCompletion
+	receivedBeforeSecond []ComponentCompletion
+	First                completionTest_First
+	Second               completionTest_Second
+}
+
+func (*completionTest_Plan) IsSequentialCTEPlan() bool {
+	return true
+}
+
+func (p *completionTest_Plan) Execute(ctx context.Context) error {
+	return p.engine.ExecuteMasterPlan(ctx, p)
+}
+
+func TestEngine_ExecuteMasterPlan_CompletionSubscription(t *testing.T) {
+	e := NewEngine()
+	e.AnalyzePlan(&completionTest_Plan{})
+
+	first := ComponentCompletion{
+		ID:    extractFullNameFromValue(completionTest_First{}),
+		Value: 1,
+	}
+
+	secondID := extractFullNameFromValue(completionTest_Second{})
+	secondErr := errors.New("second failed")
+
+	scenarios := []struct {
+		desc      string
+		subscribe func(ctx context.Context) (context.Context, func() []ComponentCompletion)
+	}{
+		{
+			desc: "handler",
+			subscribe: func(ctx context.Context) (context.Context, func() []ComponentCompletion) {
+				var mu sync.Mutex
+				var received []ComponentCompletion
+
+				ctx = ContextWithCompletionHandler(
+					ctx, func(c ComponentCompletion) {
+						mu.Lock()
+						defer mu.Unlock()
+
+						received = append(received, c)
+					},
+				)
+
+				return ctx, func() []ComponentCompletion {
+					mu.Lock()
+					defer mu.Unlock()
+
+					return append([]ComponentCompletion{}, received...)
+				}
+			},
+		},
+		{
+			desc: "channel",
+			subscribe: func(ctx context.Context) (context.Context, func() []ComponentCompletion) {
+				ch := make(chan ComponentCompletion, 2)
+				var received []ComponentCompletion
+
+				return ContextWithCompletionChannel(ctx, ch), func() []ComponentCompletion {
+					for len(ch) > 0 {
+						received = append(received, <-ch)
+					}
+
+					return append([]ComponentCompletion{}, received...)
+				}
+			},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		sc := scenario
+		t.Run(
+			sc.desc, func(t *testing.T) {
+				ctx, received := sc.subscribe(context.Background())
+
+				p := &completionTest_Plan{
+					engine:    e,
+					secondErr: secondErr,
+					received:  received,
+				}
+
+				err := p.Execute(ctx)
+				assert.Equal(t, secondErr, err)
+
+				// The first component is delivered before the second one even starts
+				assert.Equal(t, []ComponentCompletion{first}, p.receivedBeforeSecond)
+
+				expected := []ComponentCompletion{
+					first,
+					{
+						ID:  secondID,
+						Err: secondErr,
+					},
+				}
+
+				assert.Equal(t, expected, received())
+			},
+		)
+	}
+}
+
+func TestEngine_ExecuteMasterPlan_CompletionChannel_Cancelled(t *testing.T) {
+	e := NewEngine()
+	e.AnalyzePlan(&completionTest_Plan{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Cancel the execution as soon as the first component completes while nobody drains the channel
+	ctx = ContextWithCompletionHandler(
+		ctx, func(c ComponentCompletion) {
+			cancel()
+		},
+	)
+
+	ctx = ContextWithCompletionChannel(ctx, make(chan ComponentCompletion))
+
+	p := &completionTest_Plan{
+		engine: e,
+		received: func() []ComponentCompletion {
+			return nil
+		},
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- p.Execute(ctx)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		assert.Fail(t, "execution is blocked sending to the completion channel")
+	}
+}
@@ -44,6 +44,21 @@ type LoadingComputer interface {
 	Load(ctx context.Context, p MasterPlan) (interface{}, error)
 }
 
+// DeduplicatedLoadingComputer can be implemented by any LoadingComputer to let concurrent
+// executions that require the same key share a single in-flight Load call. All executions
+// sharing the call receive the same data and error.
+//
+// Note: The shared call runs using a context that carries the values of the execution that
+// started it but not its cancellation or deadline, so that one cancelled execution cannot
+// fail the others. Each execution stops waiting once its own context is done. Load must
+// therefore enforce its own timeout, e.g. on the client it calls.
+type DeduplicatedLoadingComputer interface {
+	LoadingComputer
+	// LoadKey returns the key identifying the data to load for the given plan. Returning
+	// an empty key skips deduplication entirely for this particular execution.
+	LoadKey(p MasterPlan) string
+}
+
 type LoadingData struct {
 	Data interface{}
 	Err  error
@@ -0,0 +1,228 @@
+package ctetest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+
+	"github.com/jamestrandung/go-cte-117/cte"
+)
+
+// Values holds arbitrary values decoded from a test case file.
+type Values map[string]interface{}
+
+// Decode copies the values into the given target, typically a pointer to a struct whose
+// exported field names match the keys, using a JSON round trip.
+func (v Values) Decode(target interface{}) error {
+	return decodeInto(v, target)
+}
+
+// Case describes a test case for a master plan. Case files are written in YAML, or JSON which
+// is a subset of YAML, and list their cases under a top-level `cases` key:
+//
+//	cases:
+//	  - name: fixed cost disabled
+//	    input:
+//	      PointA: Clementi
+//	    stubs:
+//	      travelplan.TravelPlan:
+//	        outcome: {Distance: 4, Duration: 5}
+//	      costconfigs.CostConfigs:
+//	        error: configs unavailable
+//	        delay: 10ms
+//	    expect:
+//	      GetTotalCost: 53.9
+type Case struct {
+	Name string `yaml:"name"`
+	// Input is passed as is to the function creating the master plan.
+	Input Values `yaml:"input"`
+	// Stubs are keyed by the type of the component key as printed by reflect, e.g.
+	// `travelplan.TravelPlan`. Components must be made stubbable via WithStubbable.
+	Stubs map[string]StubSpec `yaml:"stubs"`
+	// Expect holds the expected return values of getters on the master plan, keyed by
+	// method name. Values are converted to the return type of the getters before comparing.
+	Expect map[string]interface{} `yaml:"expect"`
+	// ExpectError is the expected error message of the execution, if any.
+	ExpectError string `yaml:"expectError"`
+}
+
+// StubSpec describes the Stub of a component in a Case.
+type StubSpec struct {
+	// Outcome is converted to the outcome type declared via WithStubbable.
+	Outcome interface{} `yaml:"outcome"`
+	// Error makes the stub fail with an error carrying this message instead.
+	Error string `yaml:"error"`
+	// Delay is a duration like `10ms` to wait before producing the outcome or error.
+	Delay string `yaml:"delay"`
+}
+
+type caseFile struct {
+	Cases []Case `yaml:"cases"`
+}
+
+type stubbable struct {
+	key         cte.MetadataProvider
+	outcomeType reflect.Type
+}
+
+type caseRunnerConfigs struct {
+	stubbables     map[string]stubbable
+	harnessOptions []Option
+}
+
+type CaseOption func(*caseRunnerConfigs)
+
+// WithStubbable allows cases to stub the component with the given key. Stubbed outcomes are
+// converted to the type of the given outcome prototype, e.g. mapservice.Route{}. The prototype
+// can be nil if cases only stub errors for this component.
+func WithStubbable(key cte.MetadataProvider, outcomePrototype interface{}) CaseOption {
+	return func(configs *caseRunnerConfigs) {
+		configs.stubbables[reflect.TypeOf(key).String()] = stubbable{
+			key:         key,
+			outcomeType: reflect.TypeOf(outcomePrototype),
+		}
+	}
+}
+
+// WithHarnessOptions applies the given options to the Harness executing every case.
+func WithHarnessOptions(options ...Option) CaseOption {
+	return func(configs *caseRunnerConfigs) {
+		configs.harnessOptions = append(configs.harnessOptions, options...)
+	}
+}
+
+// RunCases runs every case in the given file as a subtest. Each case executes the master plan
+// returned by newPlan for its input on a fresh Harness.
+func RunCases(t *testing.T, path string, newPlan func(input Values) (cte.MasterPlan, error), options ...CaseOption) {
+	configs := &caseRunnerConfigs{
+		stubbables: make(map[string]stubbable),
+	}
+
+	for _, o := range options {
+		o(configs)
+	}
+
+	cases, err := LoadCases(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range cases {
+		tc := c
+
+		t.Run(
+			tc.Name, func(t *testing.T) {
+				runCase(t, configs, tc, newPlan)
+			},
+		)
+	}
+}
+
+// LoadCases reads the cases in the given file.
+func LoadCases(path string) ([]Case, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var cf caseFile
+	if err := yaml.Unmarshal(content, &cf); err != nil {
+		return nil, fmt.Errorf("failed to parse %v: %w", path, err)
+	}
+
+	return cf.Cases, nil
+}
+
+func runCase(t *testing.T, configs *caseRunnerConfigs, c Case, newPlan func(input Values) (cte.MasterPlan, error)) {
+	p, err := newPlan(c.Input)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	harnessOptions := append([]Option{}, configs.harnessOptions...)
+	for name, spec := range c.Stubs {
+		s, ok := configs.stubbables[name]
+		if !ok {
+			t.Fatalf("component [%v] is not stubbable, declare it using WithStubbable", name)
+		}
+
+		stub, err := spec.toStub(s.outcomeType)
+		if err != nil {
+			t.Fatalf("invalid stub for component [%v]: %v", name, err)
+		}
+
+		harnessOptions = append(harnessOptions, WithStub(s.key, stub))
+	}
+
+	h := New(p, harnessOptions...)
+
+	err = h.Execute(context.Background(), p)
+	if c.ExpectError != "" {
+		if assert.Error(t, err) {
+			assert.Equal(t, c.ExpectError, err.Error())
+		}
+	} else {
+		assert.Nil(t, err)
+	}
+
+	pv := reflect.ValueOf(p)
+	for name, expected := range c.Expect {
+		getter := pv.MethodByName(name)
+		if !getter.IsValid() || getter.Type().NumIn() != 0 || getter.Type().NumOut() != 1 {
+			t.Errorf("%v is not a getter of %v", name, pv.Type())
+			continue
+		}
+
+		expectedValue := reflect.New(getter.Type().Out(0))
+		if err := decodeInto(expected, expectedValue.Interface()); err != nil {
+			t.Errorf("invalid expected value for %v: %v", name, err)
+			continue
+		}
+
+		assert.Equal(t, expectedValue.Elem().Interface(), getter.Call(nil)[0].Interface(), name)
+	}
+}
+
+func (s StubSpec) toStub(outcomeType reflect.Type) (Stub, error) {
+	var stub Stub
+	if s.Error != "" {
+		stub = Error(errors.New(s.Error))
+	} else if s.Outcome == nil || outcomeType == nil {
+		stub = Outcome(s.Outcome)
+	} else {
+		outcome := reflect.New(outcomeType)
+		if err := decodeInto(s.Outcome, outcome.Interface()); err != nil {
+			return nil, err
+		}
+
+		stub = Outcome(outcome.Elem().Interface())
+	}
+
+	if s.Delay == "" {
+		return stub, nil
+	}
+
+	delay, err := time.ParseDuration(s.Delay)
+	if err != nil {
+		return nil, err
+	}
+
+	return Delay(delay, stub), nil
+}
+
+func decodeInto(value interface{}, target interface{}) error {
+	content, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(content, target)
+}
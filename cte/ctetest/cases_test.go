@@ -0,0 +1,39 @@
+package ctetest
+
+import (
+	"testing"
+
+	"github.com/jamestrandung/go-cte-117/cte"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunCases(t *testing.T) {
+	RunCases(
+		t, "testdata/cases.json", func(input Values) (cte.MasterPlan, error) {
+			var in struct {
+				PointA string
+			}
+
+			if err := input.Decode(&in); err != nil {
+				return nil, err
+			}
+
+			return &testPlan{
+				pointA: in.PointA,
+			}, nil
+		},
+		WithStubbable(Distance{}, 0.0),
+	)
+}
+
+func TestLoadCases(t *testing.T) {
+	cases, err := LoadCases("testdata/cases.json")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(cases))
+	assert.Equal(t, "stubbed distance", cases[0].Name)
+	assert.Equal(t, Values{"PointA": "Clementi"}, cases[0].Input)
+	assert.Equal(t, StubSpec{Outcome: 3, Delay: "1ms"}, cases[0].Stubs["ctetest.Distance"])
+
+	_, err = LoadCases("testdata/missing.yaml")
+	assert.Error(t, err)
+}
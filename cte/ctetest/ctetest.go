@@ -0,0 +1,135 @@
+// Package ctetest provides utilities to test master plans in isolation, with any component
+// replaced by a stub, while recording how every component was executed.
+package ctetest
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/jamestrandung/go-cte-117/cte"
+)
+
+// Harness executes master plans using an isolated Engine in which stubbed components never
+// touch their real computers.
+type Harness struct {
+	engine   cte.Engine
+	recorder *recorder
+}
+
+type harnessConfigs struct {
+	engineOptions []cte.EngineOption
+	plans         []cte.Plan
+}
+
+type Option func(*harnessConfigs)
+
+// WithStub replaces the computer of the component with the given key, e.g. travelplan.TravelPlan{},
+// with the given Stub. Loaders of stubbed components are never invoked.
+func WithStub(key cte.MetadataProvider, stub Stub) Option {
+	return func(configs *harnessConfigs) {
+		configs.engineOptions = append(
+			configs.engineOptions, cte.WithComputerSubstitute(
+				key, stubComputer{
+					stub: stub,
+				},
+			),
+		)
+	}
+}
+
+// WithPlans makes Harness analyze the given plans on top of the master plan under test. Plans
+// returned by a SwitchComputer must be provided using this option since they are not reachable
+// from the master plan itself.
+func WithPlans(plans ...cte.Plan) Option {
+	return func(configs *harnessConfigs) {
+		configs.plans = append(configs.plans, plans...)
+	}
+}
+
+// WithEngineOptions applies the given options to the isolated Engine.
+func WithEngineOptions(options ...cte.EngineOption) Option {
+	return func(configs *harnessConfigs) {
+		configs.engineOptions = append(configs.engineOptions, options...)
+	}
+}
+
+// New returns a Harness for the given master plan.
+func New(p cte.MasterPlan, options ...Option) *Harness {
+	configs := &harnessConfigs{}
+	for _, o := range options {
+		o(configs)
+	}
+
+	r := &recorder{}
+
+	engineOptions := append([]cte.EngineOption{cte.WithInterceptor(r.intercept)}, configs.engineOptions...)
+
+	e := cte.NewEngine(engineOptions...)
+	e.AnalyzePlan(p)
+
+	for _, plan := range configs.plans {
+		e.AnalyzePlan(plan)
+	}
+
+	return &Harness{
+		engine:   e,
+		recorder: r,
+	}
+}
+
+// Engine returns the isolated Engine of this Harness.
+func (h *Harness) Engine() cte.Engine {
+	return h.engine
+}
+
+// Execute calls the Execute method of the given plan with a context that routes the execution
+// to the isolated Engine, even if the plan is hardwired to a global Engine.
+func (h *Harness) Execute(ctx context.Context, p cte.MasterPlan) error {
+	return p.Execute(cte.ContextWithEngine(ctx, h.engine))
+}
+
+// Executions returns the executions of all components in the order they started computing.
+// Components of candidate plans discarded by a cte.SpeculativeSwitchComputer are left out.
+func (h *Harness) Executions() []Execution {
+	return h.recorder.snapshot()
+}
+
+// ExecutionOrder returns the IDs of the components in the order they started computing.
+func (h *Harness) ExecutionOrder() []string {
+	executions := h.recorder.snapshot()
+
+	result := make([]string, 0, len(executions))
+	for _, e := range executions {
+		result = append(result, e.ComponentID)
+	}
+
+	return result
+}
+
+// Execution returns the first execution of the component with the given key.
+func (h *Harness) Execution(key cte.MetadataProvider) (Execution, bool) {
+	componentID := ComponentID(key)
+
+	for _, e := range h.recorder.snapshot() {
+		if e.ComponentID == componentID {
+			return e, true
+		}
+	}
+
+	return Execution{}, false
+}
+
+// Reset clears all recorded executions.
+func (h *Harness) Reset() {
+	h.recorder.reset()
+}
+
+// ComponentID returns the ID that Engine uses for the component with the given key.
+func ComponentID(key cte.MetadataProvider) string {
+	t := reflect.TypeOf(key)
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	return t.PkgPath() + "/" + t.Name()
+}
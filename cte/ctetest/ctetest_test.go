@@ -0,0 +1,283 @@
+package ctetest
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jamestrandung/go-cte-117/cte"
+	"github.com/stretchr/testify/assert"
+)
+
+var globalEngine = cte.NewEngine()
+
+type distanceComputer struct{}
+
+func (distanceComputer) Compute(ctx context.Context, p cte.MasterPlan) (interface{}, error) {
+	panic("real dependencies must not be called")
+}
+
+type distanceInout interface {
+	GetPointA() string
+}
+
+type Distance cte.SyncResult
+
+func (Distance) CTEMetadata() interface{} {
+	return struct {
+		computer distanceComputer
+		inout    distanceInout
+	}{}
+}
+
+func (d Distance) GetDistance() float64 {
+	return d.Outcome.(float64)
+}
+
+type costComputer struct{}
+
+func (costComputer) Compute(ctx context.Context, p cte.MasterPlan) (interface{}, error) {
+	return p.(costInout).GetDistance() * 2, nil
+}
+
+type costInout interface {
+	GetDistance() float64
+	SetCost(float64)
+}
+
+type Cost cte.SyncResult
+
+func (Cost) CTEMetadata() interface{} {
+	return struct {
+		computer costComputer
+		inout    costInout
+	}{}
+}
+
+func (c Cost) GetCost() float64 {
+	return c.Outcome.(float64)
+}
+
+type testPlan struct {
+	pointA string
+	cost   float64
+	Distance
+	Cost
+}
+
+func (p *testPlan) GetPointA() string {
+	return p.pointA
+}
+
+func (p *testPlan) SetCost(cost float64) {
+	p.cost = cost
+}
+
+func (*testPlan) IsSequentialCTEPlan() bool {
+	return true
+}
+
+func (p *testPlan) Execute(ctx context.Context) error {
+	return globalEngine.ExecuteMasterPlan(ctx, p)
+}
+
+func TestHarness(t *testing.T) {
+	h := New(&testPlan{}, WithStub(Distance{}, Outcome(3.0)))
+
+	p := &testPlan{
+		pointA: "Clementi",
+	}
+
+	assert.Nil(t, h.Execute(context.Background(), p))
+	assert.Equal(t, 6.0, p.GetCost())
+
+	assert.Equal(t, []string{ComponentID(Distance{}), ComponentID(Cost{})}, h.ExecutionOrder())
+
+	execution, ok := h.Execution(Distance{})
+	assert.True(t, ok)
+	assert.Equal(t, map[string]interface{}{"GetPointA": "Clementi"}, execution.Inout)
+	assert.Equal(t, 3.0, execution.Outcome)
+	assert.Nil(t, execution.Err)
+
+	execution, ok = h.Execution(Cost{})
+	assert.True(t, ok)
+	assert.Equal(t, map[string]interface{}{"GetDistance": 3.0}, execution.Inout)
+	assert.Equal(t, 6.0, execution.Outcome)
+
+	h.Reset()
+	assert.Empty(t, h.Executions())
+}
+
+type otherComputer struct{}
+
+func (otherComputer) Compute(ctx context.Context, p cte.MasterPlan) (interface{}, error) {
+	close(p.(*otherPlan).started)
+
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+type Other cte.SyncResult
+
+func (Other) CTEMetadata() interface{} {
+	return struct {
+		computer otherComputer
+	}{}
+}
+
+type otherPlan struct {
+	started chan struct{}
+	Other
+}
+
+func (*otherPlan) IsSequentialCTEPlan() bool {
+	return true
+}
+
+func (p *otherPlan) Execute(ctx context.Context) error {
+	return globalEngine.ExecuteMasterPlan(ctx, p)
+}
+
+type chosenComputer struct{}
+
+func (chosenComputer) Compute(ctx context.Context, p cte.MasterPlan) (interface{}, error) {
+	return "chosen", nil
+}
+
+type Chosen cte.SyncResult
+
+func (Chosen) CTEMetadata() interface{} {
+	return struct {
+		computer chosenComputer
+	}{}
+}
+
+type chosenPlan struct {
+	Chosen
+}
+
+func (*chosenPlan) IsSequentialCTEPlan() bool {
+	return true
+}
+
+func (p *chosenPlan) Execute(ctx context.Context) error {
+	return globalEngine.ExecuteMasterPlan(ctx, p)
+}
+
+type branchComputer struct{}
+
+// Switch decides once the discarded candidate has started executing its component
+func (branchComputer) Switch(ctx context.Context, p cte.MasterPlan) (cte.MasterPlan, error) {
+	<-p.(*speculatingPlan).started
+	return &chosenPlan{}, nil
+}
+
+func (branchComputer) Candidates(p cte.MasterPlan) []cte.MasterPlan {
+	return []cte.MasterPlan{
+		&otherPlan{started: p.(*speculatingPlan).started},
+		&chosenPlan{},
+	}
+}
+
+type Branch cte.SyncResult
+
+func (Branch) CTEMetadata() interface{} {
+	return struct {
+		computer branchComputer
+	}{}
+}
+
+type speculatingPlan struct {
+	started chan struct{}
+	Branch
+}
+
+func (*speculatingPlan) IsSequentialCTEPlan() bool {
+	return true
+}
+
+func (p *speculatingPlan) Execute(ctx context.Context) error {
+	return globalEngine.ExecuteMasterPlan(ctx, p)
+}
+
+func TestHarness_DiscardedCandidate(t *testing.T) {
+	h := New(&speculatingPlan{}, WithPlans(&chosenPlan{}, &otherPlan{}))
+
+	assert.Nil(t, h.Execute(context.Background(), &speculatingPlan{started: make(chan struct{})}))
+	assert.Equal(t, []string{ComponentID(Branch{}), ComponentID(Chosen{})}, h.ExecutionOrder())
+}
+
+func TestStubs(t *testing.T) {
+	scenarios := []struct {
+		desc            string
+		stub            Stub
+		ctxTimeout      time.Duration
+		expectedOutcome interface{}
+		expectedErr     error
+	}{
+		{
+			desc:            "outcome",
+			stub:            Outcome(1),
+			expectedOutcome: 1,
+		},
+		{
+			desc:        "error",
+			stub:        Error(assert.AnError),
+			expectedErr: assert.AnError,
+		},
+		{
+			desc:            "delay",
+			stub:            Delay(time.Millisecond, Outcome(1)),
+			expectedOutcome: 1,
+		},
+		{
+			desc:        "delay beyond deadline",
+			stub:        Delay(time.Second, Outcome(1)),
+			ctxTimeout:  10 * time.Millisecond,
+			expectedErr: context.DeadlineExceeded,
+		},
+		{
+			desc: "func",
+			stub: Func(
+				func(ctx context.Context, p cte.MasterPlan) (interface{}, error) {
+					return p, nil
+				},
+			),
+			expectedOutcome: (*testPlan)(nil),
+		},
+	}
+
+	for _, scenario := range scenarios {
+		sc := scenario
+
+		t.Run(
+			sc.desc, func(t *testing.T) {
+				ctx := context.Background()
+				if sc.ctxTimeout > 0 {
+					var cancel context.CancelFunc
+					ctx, cancel = context.WithTimeout(ctx, sc.ctxTimeout)
+					defer cancel()
+				}
+
+				outcome, err := stubComputer{stub: sc.stub}.Compute(ctx, (*testPlan)(nil))
+				assert.Equal(t, sc.expectedOutcome, outcome)
+				assert.Equal(t, sc.expectedErr, err)
+			},
+		)
+	}
+}
+
+func TestGetters(t *testing.T) {
+	p := &testPlan{
+		pointA: "Clementi",
+		Cost: Cost{
+			Outcome: 2.0,
+		},
+	}
+
+	// Getters of Distance panic since it has no outcome
+	assert.Equal(t, map[string]interface{}{"GetPointA": "Clementi", "GetCost": 2.0}, Getters(p, nil))
+	assert.Equal(t, map[string]interface{}{"GetPointA": "Clementi"}, Getters(p, reflect.TypeOf((*distanceInout)(nil)).Elem()))
+	assert.Empty(t, Getters(nil, nil))
+}
@@ -0,0 +1,126 @@
+package ctetest
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/jamestrandung/go-cte-117/cte"
+)
+
+// Execution describes one execution of a component.
+type Execution struct {
+	ComponentID string
+	// Inout holds the values returned by the getters of the inout interface of the component,
+	// keyed by method name, right before the component was computed. Getters that panicked
+	// are left out.
+	Inout map[string]interface{}
+	// Outcome is always nil for components using a SwitchComputer.
+	Outcome interface{}
+	Err     error
+}
+
+type recorder struct {
+	mu         sync.Mutex
+	executions []*Execution
+}
+
+func (r *recorder) intercept(d cte.ComponentDescription) cte.Interceptor {
+	return func(ctx context.Context, p cte.MasterPlan, next func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+		execution := &Execution{
+			ComponentID: d.ID,
+			Inout:       Getters(p, d.Inout),
+		}
+
+		// Candidates discarded by a SpeculativeSwitchComputer must not show up
+		cte.WhenKept(
+			ctx, func() {
+				r.mu.Lock()
+				r.executions = append(r.executions, execution)
+				r.mu.Unlock()
+			},
+		)
+
+		outcome, err := next(ctx)
+
+		r.mu.Lock()
+		if !d.Switch {
+			execution.Outcome = outcome
+		}
+
+		execution.Err = err
+		r.mu.Unlock()
+
+		return outcome, err
+	}
+}
+
+func (r *recorder) snapshot() []Execution {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	result := make([]Execution, 0, len(r.executions))
+	for _, e := range r.executions {
+		result = append(result, *e)
+	}
+
+	return result
+}
+
+func (r *recorder) reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.executions = nil
+}
+
+// Getters invokes the getters of the given value and returns their results keyed by method
+// name. If iface is an interface, its methods that take no argument and return a single value
+// are invoked. Otherwise, the methods of the value named Get* that take no argument and return
+// a single value are invoked. Getters that panic are left out.
+func Getters(v interface{}, iface reflect.Type) map[string]interface{} {
+	result := make(map[string]interface{})
+	if v == nil {
+		return result
+	}
+
+	rv := reflect.ValueOf(v)
+
+	methods := rv.Type()
+	if iface != nil {
+		if iface.Kind() != reflect.Interface {
+			return result
+		}
+
+		methods = iface
+	}
+
+	for i := 0; i < methods.NumMethod(); i++ {
+		method := methods.Method(i)
+		if iface == nil && !strings.HasPrefix(method.Name, "Get") {
+			continue
+		}
+
+		getter := rv.MethodByName(method.Name)
+		if !getter.IsValid() || getter.Type().NumIn() != 0 || getter.Type().NumOut() != 1 {
+			continue
+		}
+
+		if value, ok := callGetter(getter); ok {
+			result[method.Name] = value
+		}
+	}
+
+	return result
+}
+
+func callGetter(getter reflect.Value) (value interface{}, ok bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			value, ok = nil, false
+		}
+	}()
+
+	return getter.Call(nil)[0].Interface(), true
+}
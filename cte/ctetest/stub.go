@@ -0,0 +1,55 @@
+package ctetest
+
+import (
+	"context"
+	"time"
+
+	"github.com/jamestrandung/go-cte-117/cte"
+)
+
+// Stub produces the outcome of a stubbed component in place of its real computer.
+type Stub func(ctx context.Context, p cte.MasterPlan) (interface{}, error)
+
+// Outcome returns a Stub that always produces the given outcome.
+func Outcome(outcome interface{}) Stub {
+	return func(ctx context.Context, p cte.MasterPlan) (interface{}, error) {
+		return outcome, nil
+	}
+}
+
+// Error returns a Stub that always fails with the given error.
+func Error(err error) Stub {
+	return func(ctx context.Context, p cte.MasterPlan) (interface{}, error) {
+		return nil, err
+	}
+}
+
+// Delay returns a Stub that waits for the given duration before delegating to the given
+// Stub. If the context is done first, the returned Stub fails with the context error.
+func Delay(d time.Duration, then Stub) Stub {
+	return func(ctx context.Context, p cte.MasterPlan) (interface{}, error) {
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+
+		select {
+		case <-timer.C:
+			return then(ctx, p)
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// Func returns a Stub that produces its outcome using the given function.
+func Func(fn func(ctx context.Context, p cte.MasterPlan) (interface{}, error)) Stub {
+	return fn
+}
+
+// stubComputer substitutes the real computer of a stubbed component. It never loads data.
+type stubComputer struct {
+	stub Stub
+}
+
+func (c stubComputer) Compute(ctx context.Context, p cte.MasterPlan) (interface{}, error) {
+	return c.stub(ctx, p)
+}
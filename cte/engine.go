@@ -2,17 +2,23 @@ package cte
 
 import (
     "context"
+    "errors"
     "fmt"
     "reflect"
     "runtime/debug"
+    "time"
 
     "github.com/jamestrandung/go-concurrency-117/async"
     "golang.org/x/sync/errgroup"
+    "golang.org/x/sync/singleflight"
 )
 
 type registeredComputer struct {
-    computer delegatingComputer
-    metadata parsedMetadata
+    computer          delegatingComputer
+    instance          interface{}
+    metadata          parsedMetadata
+    cachePolicy       CachePolicy
+    loaderErrorPolicy LoaderErrorPolicy
 }
 
 //go:generate mockery --name iEngine --case=underscore --inpackage
@@ -25,12 +31,30 @@ type iEngine interface {
 }
 
 type Engine struct {
+    configs   *engineConfigs
+    loads     loadGroup
+    lifecycle *lifecycle
+    factories map[string]func() interface{}
     computers map[string]registeredComputer
     plans     map[string]analyzedPlan
 }
 
-func NewEngine() Engine {
+func NewEngine(options ...EngineOption) Engine {
+    configs := &engineConfigs{
+        options: options,
+    }
+
+    for _, o := range options {
+        o(configs)
+    }
+
+    newShadowEngines(configs)
+
     return Engine{
+        configs:   configs,
+        loads:     &singleflight.Group{},
+        lifecycle: &lifecycle{},
+        factories: make(map[string]func() interface{}),
         computers: make(map[string]registeredComputer),
         plans:     make(map[string]analyzedPlan),
     }
@@ -51,6 +75,49 @@ func (e Engine) AnalyzePlan(p Plan) {
     ap := pa.analyze()
 
     e.plans[planName] = ap
+
+    for _, sc := range e.configs.shadows {
+        if sc.engine != nil {
+            sc.engine.AnalyzePlan(p)
+        }
+    }
+}
+
+// RegisterComputerFactory makes Engine build the computer of the given component using
+// the given factory instead of instantiating a zero value of the type declared under the
+// `computer` key in CTEMetadata. This allows computers to hold dependencies like clients,
+// loggers and configs instead of reading them from the plan. The factory is invoked once
+// and must return a value, or a pointer to a value, of the declared computer type.
+//
+// If the component was already registered when analyzing a plan, its computer will be
+// replaced in all plans analyzed so far. This method is not safe for concurrent use and
+// should be called during initialization, before executing any plan.
+func (e Engine) RegisterComputerFactory(key MetadataProvider, factory func() interface{}) {
+    computerID := extractFullNameFromValue(key)
+    e.factories[computerID] = factory
+
+    for _, sc := range e.configs.shadows {
+        if sc.engine != nil {
+            sc.engine.RegisterComputerFactory(key, factory)
+        }
+    }
+
+    if _, ok := e.computers[computerID]; !ok {
+        return
+    }
+
+    delete(e.computers, computerID)
+    e.registerComputer(key)
+
+    for planName, ap := range e.plans {
+        pa := &planAnalyzer{
+            engine:     e,
+            components: ap.components,
+        }
+
+        ap.loaders = pa.extractLoaders()
+        e.plans[planName] = ap
+    }
 }
 
 func (e Engine) registerComputer(mp MetadataProvider) {
@@ -70,29 +137,202 @@ func (e Engine) registerComputer(mp MetadataProvider) {
         return extractNonPointerType(cType)
     }()
 
-    computer := reflect.New(computerType).Interface()
-    e.computers[computerID] = registeredComputer{
-        computer: newDelegatingComputer(computer),
-        metadata: metadata,
+    cachePolicy := func() CachePolicy {
+        // Outcomes of a substitute must not be mixed up with those of the original computer
+        if _, ok := e.configs.substitutes[computerID]; ok {
+            return nil
+        }
+
+        cpType, ok := metadata.getCachePolicyType()
+        if !ok {
+            return nil
+        }
+
+        cp, ok := reflect.New(extractNonPointerType(cpType)).Interface().(CachePolicy)
+        if !ok {
+            panic(ErrInvalidCachePolicy.Err(cpType, reflect.TypeOf(mp)))
+        }
+
+        return cp
+    }()
+
+    loaderErrorPolicy := func() LoaderErrorPolicy {
+        lepType, ok := metadata.getLoaderErrorPolicyType()
+        if !ok {
+            return LoaderErrorPassThrough
+        }
+
+        lep, ok := loaderErrorPolicies[extractNonPointerType(lepType)]
+        if !ok {
+            panic(ErrInvalidLoaderErrorPolicy.Err(lepType, reflect.TypeOf(mp)))
+        }
+
+        return lep
+    }()
+
+    computer := func() interface{} {
+        if substitute, ok := e.configs.substitutes[computerID]; ok {
+            return substitute
+        }
+
+        factory, ok := e.factories[computerID]
+        if !ok {
+            return reflect.New(computerType).Interface()
+        }
+
+        result := factory()
+        if result == nil || extractNonPointerType(reflect.TypeOf(result)) != computerType {
+            panic(ErrFactoryTypeMismatch.Err(reflect.TypeOf(result), reflect.TypeOf(mp), computerType))
+        }
+
+        return result
+    }()
+
+    // A cached outcome would silently skip the side effects
+    if cachePolicy != nil {
+        switch computer.(type) {
+        case SideEffectComputer, SideEffectComputerWithLoadingData:
+            cpType, _ := metadata.getCachePolicyType()
+            panic(ErrCachePolicyOnSideEffect.Err(cpType, reflect.TypeOf(mp), reflect.TypeOf(computer)))
+        }
+    }
+
+    dc := newDelegatingComputer(computer)
+    if dlc, ok := computer.(DeduplicatedLoadingComputer); ok {
+        dc.loadFn = deduplicateLoads(e.loads, computerID, dlc, dc.loadFn)
+    }
+
+    rc := registeredComputer{
+        computer:          dc,
+        instance:          computer,
+        metadata:          metadata,
+        cachePolicy:       cachePolicy,
+        loaderErrorPolicy: loaderErrorPolicy,
+    }
+
+    // Engine-level interceptors wrap around the ones attached by metadata extensions
+    var interceptors []Interceptor
+    for _, factory := range e.configs.interceptors {
+        if interceptor := factory(rc.describe(computerID)); interceptor != nil {
+            interceptors = append(interceptors, interceptor)
+        }
+    }
+
+    interceptors = append(interceptors, extractInterceptors(computerID, metadata)...)
+
+    rc.computer.computeFn = intercept(dc.computeFn, interceptors)
+
+    if dc.loadFn != nil {
+        var loaderInterceptors []Interceptor
+        for _, factory := range e.configs.loaderInterceptors {
+            if interceptor := factory(rc.describe(computerID)); interceptor != nil {
+                loaderInterceptors = append(loaderInterceptors, interceptor)
+            }
+        }
+
+        rc.computer.loadFn = interceptLoad(dc.loadFn, loaderInterceptors)
     }
+
+    e.computers[computerID] = rc
 }
 
 func (e Engine) ExecuteMasterPlan(ctx context.Context, p MasterPlan) error {
+    if override, ok := extractEngine(ctx); ok && override.configs != e.configs {
+        return override.ExecuteMasterPlan(ctx, p)
+    }
+
+    ctx, exit, err := e.lifecycle.enter(ctx)
+    if err != nil {
+        return err
+    }
+
+    defer exit()
+
     // Plan implementations always use pointer receivers.
     // Should be safe to extract value.
     planValue := reflect.ValueOf(p).Elem()
 
     planName := extractFullNameFromType(planValue.Type())
+
+    runShadow := e.startShadow(planName, p)
+
+    if e.configs.hoistLoaders {
+        loadingCtx, cancelLoading := context.WithCancel(ctx)
+        defer cancelLoading()
+
+        ctx = withPreloadedData(ctx, e.doHoistedLoading(loadingCtx, planName, p))
+    }
+
     if err := e.doExecutePlan(ctx, planName, p, planValue, p.IsSequentialCTEPlan()); err != nil {
-        return swallowErrPlanExecutionEndingEarly(err)
+        deliverEarlyOutcome(ctx, err)
+
+        err = swallowErrPlanExecutionEndingEarly(err)
+        WhenKept(ctx, func() { runShadow(err) })
+
+        return err
     }
 
+    WhenKept(ctx, func() { runShadow(nil) })
+
     return nil
 }
 
 func (e Engine) doExecutePlan(ctx context.Context, planName string, p MasterPlan, curPlanValue reflect.Value, isSequential bool) error {
     ap := e.findAnalyzedPlan(planName, curPlanValue)
 
+    if ap.budget <= 0 {
+        return e.doExecuteAnalyzedPlan(ctx, planName, p, curPlanValue, isSequential, ap)
+    }
+
+    return e.doExecuteWithinBudget(ctx, planName, p, curPlanValue, isSequential, ap)
+}
+
+func (e Engine) doExecuteWithinBudget(
+    ctx context.Context,
+    planName string,
+    p MasterPlan,
+    curPlanValue reflect.Value,
+    isSequential bool,
+    ap analyzedPlan,
+) error {
+    // Start the clock before the deadline so that the elapsed time
+    // can never be shorter than the budget once the deadline fires.
+    startedAt := time.Now()
+
+    budgetCtx, cancel := context.WithTimeout(ctx, ap.budget)
+    defer cancel()
+
+    if pd, ok := extractPreloadedData(ctx); ok {
+        users := make(map[string]int)
+        e.collectLoaders(planName, make(map[string]loadFn), users)
+
+        defer pd.cancelOnDeadline(budgetCtx, users)()
+    }
+
+    err := e.doExecuteAnalyzedPlan(budgetCtx, planName, p, curPlanValue, isSequential, ap)
+
+    // A deadline inherited from the parent context firing first is not an overrun
+    elapsed := time.Since(startedAt)
+    if elapsed > ap.budget || (budgetCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil) {
+        e.configs.reportBudgetOverrun(
+            BudgetOverrun{
+                PlanName: planName,
+                Budget:   ap.budget,
+                Elapsed:  elapsed,
+            },
+        )
+    }
+
+    // Only blame the budget if it was this plan's own deadline that fired,
+    // not one inherited from the parent context.
+    if err != nil && budgetCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil {
+        return ErrPlanBudgetExceeded.Err(planName, ap.budget)
+    }
+
+    return err
+}
+
+func (e Engine) doExecuteAnalyzedPlan(ctx context.Context, planName string, p MasterPlan, curPlanValue reflect.Value, isSequential bool, ap analyzedPlan) error {
     for _, h := range ap.preHooks {
         if err := h.hook.PreExecute(p); err != nil {
             return err
@@ -101,7 +341,7 @@ func (e Engine) doExecutePlan(ctx context.Context, planName string, p MasterPlan
 
     err := func() error {
         if isSequential {
-            return e.doExecuteSync(ctx, p, curPlanValue, ap.loaders, ap.components)
+            return e.doExecuteSync(ctx, planName, p, curPlanValue, ap.loaders, ap.components)
         }
 
         return e.doExecuteAsync(ctx, p, curPlanValue, ap.components)
@@ -120,68 +360,246 @@ func (e Engine) doExecutePlan(ctx context.Context, planName string, p MasterPlan
     return nil
 }
 
-func (e Engine) doExecuteComputer(ctx context.Context, c delegatingComputer, p MasterPlan, loadingData LoadingData) (interface{}, error) {
-    result, err := c.Compute(ctx, p, loadingData)
+func (e Engine) doExecuteComputer(
+    ctx context.Context,
+    componentID string,
+    c registeredComputer,
+    p MasterPlan,
+    loadingData LoadingData,
+    spec *speculation,
+) (interface{}, error) {
+    defer spec.cancelAll()
+
+    if loadingData.Err != nil {
+        switch c.loaderErrorPolicy {
+        case LoaderErrorFailsComponent:
+            return nil, loadingData.Err
+        case LoaderErrorFailsPlan:
+            return nil, loaderFailure{
+                componentID: componentID,
+                err:         loadingData.Err,
+            }
+        }
+    }
+
+    result, err := e.doCompute(ctx, componentID, c, p, loadingData)
     if tep, ok := result.(toExecutePlan); ok {
         if err != nil {
             return tep.mp, err
         }
 
-        return tep.mp, tep.mp.Execute(ctx)
+        switchCtx, err := e.enterSwitch(ctx, p, tep.mp)
+        if err != nil {
+            return tep.mp, err
+        }
+
+        if branch, ok := spec.take(tep.mp); ok {
+            return branch.await()
+        }
+
+        // The switched master plan may end early with a value that
+        // should replace the plan itself as the outcome
+        planCtx, eo := CaptureEarlyOutcome(switchCtx)
+        if err := tep.mp.Execute(planCtx); err != nil {
+            return tep.mp, err
+        }
+
+        if value, ok := eo.Value(); ok {
+            return value, nil
+        }
+
+        return tep.mp, nil
+    }
+
+    return result, err
+}
+
+func (e Engine) doCompute(ctx context.Context, componentID string, c registeredComputer, p MasterPlan, loadingData LoadingData) (interface{}, error) {
+    if c.cachePolicy == nil || e.configs.cacheStore == nil {
+        return c.computer.Compute(ctx, p, loadingData)
+    }
+
+    key := c.cachePolicy.CacheKey(p)
+    if key == "" {
+        return c.computer.Compute(ctx, p, loadingData)
+    }
+
+    // Computers may share the same cache keys
+    key = componentID + "/" + key
+
+    if cached, ok := e.configs.cacheStore.Get(key); ok {
+        return cached, nil
+    }
+
+    result, err := c.computer.Compute(ctx, p, loadingData)
+    if _, isPlan := result.(toExecutePlan); err == nil && !isPlan {
+        e.configs.cacheStore.Set(key, result, c.cachePolicy.CacheTTL())
     }
 
     return result, err
 }
 
-func (e Engine) doConcurrentLoading(ctx context.Context, p MasterPlan, componentCount int, loaders []loadFn) []LoadingData {
-    // Data has to be loaded at the same index with the corresponding component
-    loadingData := make([]LoadingData, componentCount)
+// doHoistedLoading starts loading data for all components reachable from the given master
+// plan, nested plans included, without waiting for any of them to complete.
+func (e Engine) doHoistedLoading(ctx context.Context, planName string, p MasterPlan) preloadedData {
+    loaders := make(map[string]loadFn)
+    users := make(map[string]int)
+    e.collectLoaders(planName, loaders, users)
+
+    result := preloadedData{
+        tasks: make(map[string]async.Task, len(loaders)),
+        users: users,
+    }
+
+    for componentID, loader := range loaders {
+        l := loader
+
+        result.tasks[componentID] = async.Invoke(
+            ctx, func(taskCtx context.Context) (interface{}, error) {
+                return l(taskCtx, p)
+            },
+        )
+    }
+
+    return result
+}
+
+// collectLoaders collects the loaders of all components reachable from the given plan and
+// counts how many times each of these components appears in the plan tree.
+func (e Engine) collectLoaders(planName string, loaders map[string]loadFn, users map[string]int) {
+    for _, component := range e.plans[planName].components {
+        if c, ok := e.computers[component.id]; ok {
+            if c.computer.loadFn != nil {
+                loaders[component.id] = c.computer.loadFn
+                users[component.id]++
+            }
+
+            continue
+        }
+
+        if _, ok := e.plans[component.id]; ok {
+            e.collectLoaders(component.id, loaders, users)
+        }
+    }
+}
+
+// doConcurrentLoading starts loading data for all components in the background without
+// waiting for any of them to complete. Each component should only wait for its own data
+// via awaitLoadingData so that fast components are not held back by slow loaders.
+func (e Engine) doConcurrentLoading(ctx context.Context, p MasterPlan, componentCount int, loaders []loadFn) []async.Task {
+    // Tasks have to be at the same index with the corresponding component
+    loadingTasks := make([]async.Task, componentCount)
     if len(loaders) == 0 {
-        return loadingData
+        return loadingTasks
     }
 
-    tasks := make([]async.SilentTask, 0, len(loaders))
     for idx, loader := range loaders {
         if loader == nil {
             continue
         }
 
-        i := idx
         l := loader
 
-        tasks = append(
-            tasks,
-            async.NewSilentTask(
-                func(taskCtx context.Context) error {
-                    data, err := l(taskCtx, p)
+        loadingTasks[idx] = async.Invoke(
+            ctx, func(taskCtx context.Context) (interface{}, error) {
+                return l(taskCtx, p)
+            },
+        )
+    }
 
-                    loadingData[i] = LoadingData{
-                        Data: data,
-                        Err:  err,
-                    }
+    return loadingTasks
+}
 
-                    return nil
-                },
-            ),
-        )
+func awaitLoadingData(loadingTask async.Task) LoadingData {
+    if loadingTask == nil {
+        return LoadingData{}
     }
 
-    async.ForkJoinST(ctx, tasks)
+    data, err := loadingTask.Outcome()
 
-    return loadingData
+    return LoadingData{
+        Data: data,
+        Err:  err,
+    }
 }
 
 func (e Engine) doExecuteSync(
     ctx context.Context,
+    planName string,
     p MasterPlan,
     curPlanValue reflect.Value,
     loaders []loadFn,
     components []parsedComponent,
 ) error {
-    loadingData := e.doConcurrentLoading(ctx, p, len(components), loaders)
+    loadingCtx, cancelLoading := context.WithCancel(ctx)
+    defer cancelLoading()
+
+    // Hoisted tasks shared with components of other plans must keep running
+    // after this plan stops, hence only owned tasks may be cancelled.
+    loadingTasks, ownedTasks := func() ([]async.Task, []async.Task) {
+        if pd, ok := extractPreloadedData(ctx); ok {
+            users := make(map[string]int)
+            e.collectLoaders(planName, make(map[string]loadFn), users)
 
+            return pd.tasksFor(components), pd.ownedTasksFor(components, users)
+        }
+
+        loadingTasks := e.doConcurrentLoading(loadingCtx, p, len(components), loaders)
+        return loadingTasks, loadingTasks
+    }()
+
+    err := e.doExecuteSyncComponents(ctx, p, curPlanValue, loadingTasks, components)
+
+    // If the plan failed or ended early, data loaded for the components
+    // that were never reached is of no use to anyone. Loaders of skipped
+    // components are cancelled too but a successful plan is not reported.
+    if count := cancelOutstandingLoading(ownedTasks); count > 0 && err != nil {
+        cancelLoading()
+
+        e.configs.reportLoaderCancellation(
+            LoaderCancellation{
+                PlanName: planName,
+                Count:    count,
+            },
+        )
+    }
+
+    return err
+}
+
+func cancelOutstandingLoading(loadingTasks []async.Task) int {
+    count := 0
+    for _, t := range loadingTasks {
+        if t == nil {
+            continue
+        }
+
+        if s := t.State(); s == async.IsCreated || s == async.IsRunning {
+            t.Cancel()
+            count++
+        }
+    }
+
+    return count
+}
+
+func (e Engine) doExecuteSyncComponents(
+    ctx context.Context,
+    p MasterPlan,
+    curPlanValue reflect.Value,
+    loadingTasks []async.Task,
+    components []parsedComponent,
+) error {
     for idx, component := range components {
+        // Stop right away if the plan was cancelled or ran out of time
+        // while the previous component was executing.
+        if err := ctx.Err(); err != nil {
+            return err
+        }
+
         if c, ok := e.computers[component.id]; ok {
+            notifyComponentStarted(ctx, component.id)
+
             result, err := func() (result any, err error) {
                 defer func() {
                     if r := recover(); r != nil {
@@ -189,9 +607,19 @@ func (e Engine) doExecuteSync(
                     }
                 }()
 
-                return e.doExecuteComputer(ctx, c.computer, p, loadingData[idx])
+                if e.shouldSkip(ctx, component.id, c) {
+                    return nil, ErrComponentSkipped
+                }
+
+                defer e.configs.recordLatency(component.id, time.Now())
+
+                spec := e.speculate(ctx, c, p)
+
+                return e.doExecuteComputer(ctx, component.id, c, p, awaitLoadingData(loadingTasks[idx]), spec)
             }()
 
+            notifyComponentCompleted(ctx, component.id, result, err)
+
             // Register Result/SyncResult in a sequential plan's field
             if component.requireSet {
                 field := curPlanValue.Field(component.fieldIdx)
@@ -219,7 +647,7 @@ func (e Engine) doExecuteSync(
                 field.Set(resultTakingIntoAccountPointerType)
             }
 
-            if err != nil {
+            if err := swallowOptionalComponentErr(err, c.metadata.isOptional()); err != nil {
                 return err
             }
 
@@ -232,7 +660,7 @@ func (e Engine) doExecuteSync(
             nestedPlanValue := curPlanValue.Field(component.fieldIdx)
 
             err := e.doExecutePlan(ctx, component.id, p, nestedPlanValue, ap.isSequential)
-            if err != nil && err != ErrPlanExecutionEndingEarly {
+            if err != nil && !errors.Is(err, ErrPlanExecutionEndingEarly) {
                 return err
             }
         }
@@ -241,26 +669,66 @@ func (e Engine) doExecuteSync(
     return nil
 }
 
+type asyncComponent struct {
+    task       async.SilentTask
+    isOptional bool
+}
+
 func (e Engine) doExecuteAsync(ctx context.Context, p MasterPlan, curPlanValue reflect.Value, components []parsedComponent) error {
-    tasks := make([]async.SilentTask, 0, len(components))
+    tasks := make([]asyncComponent, 0, len(components))
     for _, component := range components {
         componentID := component.id
 
         if c, ok := e.computers[componentID]; ok {
             task := async.NewTask(
                 func(taskCtx context.Context) (interface{}, error) {
-                    data, err := c.computer.Load(taskCtx, p)
-
-                    return e.doExecuteComputer(
-                        taskCtx, c.computer, p, LoadingData{
-                            Data: data,
-                            Err:  err,
-                        },
-                    )
+                    notifyComponentStarted(taskCtx, componentID)
+
+                    result, err := func() (result interface{}, err error) {
+                        defer func() {
+                            if r := recover(); r != nil {
+                                err = fmt.Errorf("panic executing async task: %v \n %s", r, debug.Stack())
+                            }
+                        }()
+
+                        if e.shouldSkip(taskCtx, componentID, c) {
+                            return nil, ErrComponentSkipped
+                        }
+
+                        defer e.configs.recordLatency(componentID, time.Now())
+
+                        spec := e.speculate(taskCtx, c, p)
+
+                        data, err := func() (interface{}, error) {
+                            if pd, ok := extractPreloadedData(taskCtx); ok {
+                                if t, ok := pd.tasks[componentID]; ok {
+                                    return t.Outcome()
+                                }
+                            }
+
+                            return c.computer.Load(taskCtx, p)
+                        }()
+
+                        return e.doExecuteComputer(
+                            taskCtx, componentID, c, p, LoadingData{
+                                Data: data,
+                                Err:  err,
+                            }, spec,
+                        )
+                    }()
+
+                    notifyComponentCompleted(taskCtx, componentID, result, err)
+
+                    return result, err
                 },
             )
 
-            tasks = append(tasks, task)
+            tasks = append(
+                tasks, asyncComponent{
+                    task:       task,
+                    isOptional: c.metadata.isOptional(),
+                },
+            )
 
             // Register Result in a parallel plan's field
             if component.requireSet {
@@ -292,7 +760,7 @@ func (e Engine) doExecuteAsync(ctx context.Context, p MasterPlan, curPlanValue r
             task := async.NewSilentTask(
                 func(taskCtx context.Context) error {
                     err := e.doExecutePlan(taskCtx, componentID, p, nestedPlanValue, ap.isSequential)
-                    if err != nil && err != ErrPlanExecutionEndingEarly {
+                    if err != nil && !errors.Is(err, ErrPlanExecutionEndingEarly) {
                         return err
                     }
 
@@ -300,7 +768,11 @@ func (e Engine) doExecuteAsync(ctx context.Context, p MasterPlan, curPlanValue r
                 },
             )
 
-            tasks = append(tasks, task)
+            tasks = append(
+                tasks, asyncComponent{
+                    task: task,
+                },
+            )
         }
     }
 
@@ -309,7 +781,10 @@ func (e Engine) doExecuteAsync(ctx context.Context, p MasterPlan, curPlanValue r
         t := task
         g.Go(
             func() error {
-                return t.ExecuteSync(groupCtx).Error()
+                // Errors from optional components stay in their Result and must not
+                // cancel sibling components through the group context.
+                err := t.task.ExecuteSync(groupCtx).Error()
+                return swallowOptionalComponentErr(err, t.isOptional)
             },
         )
     }
@@ -317,6 +792,28 @@ func (e Engine) doExecuteAsync(ctx context.Context, p MasterPlan, curPlanValue r
     return g.Wait()
 }
 
+// shouldSkip returns true if the given component is optional and, according to the latency
+// the engine has recorded for it so far, cannot complete before the context deadline.
+func (e Engine) shouldSkip(ctx context.Context, componentID string, c registeredComputer) bool {
+    if e.configs.latencies == nil || !c.metadata.isOptional() {
+        return false
+    }
+
+    deadline, ok := ctx.Deadline()
+    if !ok {
+        return false
+    }
+
+    expected, ok := e.configs.latencies.percentile(componentID, e.configs.skippingPercentile)
+    if !ok || time.Until(deadline) >= expected {
+        return false
+    }
+
+    // A skipped component records no latency, let one execution through
+    // every now and then so that its latency can recover.
+    return !e.configs.latencies.probe(componentID, time.Now())
+}
+
 func (e Engine) VerifyConfigurations() error {
     for _, p := range e.plans {
         if p.isMasterPlan {
@@ -328,7 +825,7 @@ func (e Engine) VerifyConfigurations() error {
         }
     }
 
-    return nil
+    return e.detectSwitchCycles()
 }
 
 func (e Engine) findExistingPlanOrCreate(planName string) analyzedPlan {
@@ -0,0 +1,210 @@
+package cte
+
+import (
+	"time"
+)
+
+type engineConfigs struct {
+	// options are kept so that shadow engines can be configured the same way
+	options              []EngineOption
+	budgetOverrunHandler func(BudgetOverrun)
+	latencies            *latencyTracker
+	skippingPercentile   float64
+	cacheStore           CacheStore
+	cancellationHandler  func(LoaderCancellation)
+	hoistLoaders         bool
+	substitutes          map[string]interface{}
+	interceptors         []func(ComponentDescription) Interceptor
+	loaderInterceptors   []func(ComponentDescription) Interceptor
+	shadows              map[string]shadowConfigs
+	maxSwitchDepth       int
+}
+
+type EngineOption func(*engineConfigs)
+
+// BudgetOverrun describes a plan that took longer than the time budget it declared
+// by implementing BudgetedPlan.
+type BudgetOverrun struct {
+	PlanName string
+	Budget   time.Duration
+	Elapsed  time.Duration
+}
+
+// LoaderCancellation describes the loaders that Engine cancelled because the sequential
+// plan they were started for failed or ended early before reaching their components.
+type LoaderCancellation struct {
+	PlanName string
+	Count    int
+}
+
+// WithBudgetOverrunHandler sets the handler that Engine will invoke every time a plan
+// exceeds its time budget. The handler is invoked synchronously on the goroutine that
+// executed the plan and hence, should return quickly.
+func WithBudgetOverrunHandler(handler func(BudgetOverrun)) EngineOption {
+	return func(configs *engineConfigs) {
+		configs.budgetOverrunHandler = handler
+	}
+}
+
+// WithDeadlineAwareSkipping makes Engine keep track of the latency of every component and
+// skip optional components whose latency at the given percentile (e.g. 0.95 for p95) is
+// longer than the time left before the context deadline. Skipped components will carry
+// ErrComponentSkipped in their Result. A component that keeps getting skipped is still
+// executed once every few seconds so that its recorded latency can recover.
+func WithDeadlineAwareSkipping(percentile float64) EngineOption {
+	return func(configs *engineConfigs) {
+		configs.latencies = newLatencyTracker()
+		configs.skippingPercentile = percentile
+	}
+}
+
+// WithCacheStore sets the store in which Engine will cache the outcomes of computers that
+// declared a CachePolicy in their metadata. Without a store, no outcome will be cached.
+func WithCacheStore(store CacheStore) EngineOption {
+	return func(configs *engineConfigs) {
+		configs.cacheStore = store
+	}
+}
+
+// WithLoaderCancellationHandler sets the handler that Engine will invoke every time it cancels
+// outstanding loaders of a sequential plan that failed or ended early. The handler is invoked
+// synchronously on the goroutine that executed the plan and hence, should return quickly.
+func WithLoaderCancellationHandler(handler func(LoaderCancellation)) EngineOption {
+	return func(configs *engineConfigs) {
+		configs.cancellationHandler = handler
+	}
+}
+
+// WithLoaderHoisting makes Engine start every loader reachable from a master plan, nested
+// plans included, in one concurrent phase at the start of the execution instead of waiting
+// for each nested plan to be reached. A component that appears more than once in the same
+// plan tree will have its data loaded only once.
+//
+// Note: Loaders of nested plans then run before the pre-hooks of these plans and before
+// earlier components of a sequential plan had a chance to set any state on the plan. Loaders
+// must therefore only depend on the input of the master plan. Hoisted loaders are cancelled
+// once the nested plan they belong to exceeds its time budget, fails or ends early, except
+// for loaders of components that also appear in other plans of the tree. These keep running
+// until the master plan completes.
+func WithLoaderHoisting() EngineOption {
+	return func(configs *engineConfigs) {
+		configs.hoistLoaders = true
+	}
+}
+
+// WithComputerSubstitute makes Engine use the given computer for the given component instead
+// of the one declared in its CTEMetadata. Unlike RegisterComputerFactory, the substitute can
+// be of any computer type, which is meant for testing. Outcomes of a substitute are never
+// cached even if the component declared a CachePolicy.
+func WithComputerSubstitute(key MetadataProvider, computer interface{}) EngineOption {
+	return func(configs *engineConfigs) {
+		if configs.substitutes == nil {
+			configs.substitutes = make(map[string]interface{})
+		}
+
+		configs.substitutes[extractFullNameFromValue(key)] = computer
+	}
+}
+
+// WithInterceptor makes Engine wrap the Compute method of every computer with the Interceptor
+// returned by the given factory. The factory is invoked once per component when it is
+// registered and may return nil to leave the component alone. Interceptors added via this
+// option wrap around the ones attached by metadata extensions, the first option being the
+// outermost.
+func WithInterceptor(factory func(ComponentDescription) Interceptor) EngineOption {
+	return func(configs *engineConfigs) {
+		configs.interceptors = append(configs.interceptors, factory)
+	}
+}
+
+// WithLoaderInterceptor makes Engine wrap the Load method of every computer having a loader
+// with the Interceptor returned by the given factory. It follows the same rules as
+// WithInterceptor except that next returns the data loaded for the component.
+func WithLoaderInterceptor(factory func(ComponentDescription) Interceptor) EngineOption {
+	return func(configs *engineConfigs) {
+		configs.loaderInterceptors = append(configs.loaderInterceptors, factory)
+	}
+}
+
+// WithShadowPlan makes Engine execute the plan returned by build as the shadow of every execution
+// of the given master plan type. build is invoked with the primary plan before it executes and
+// must copy the inputs of the primary plan into the shadow plan. The shadow is executed
+// asynchronously after the primary execution completes, without affecting it, and the given
+// getters are compared on both plans using reflect.DeepEqual. The handler is invoked with
+// the mismatches, or when only one of the executions failed, on the goroutine of the shadow.
+//
+// Note: The primary plan must not be modified after its execution completes since it is
+// read concurrently by the shadow.
+func WithShadowPlan(primary MasterPlan, build func(p MasterPlan) MasterPlan, getters []string, handler func(ShadowReport)) EngineOption {
+	return func(configs *engineConfigs) {
+		configs.addShadow(
+			primary, shadowConfigs{
+				build:   build,
+				getters: getters,
+				handler: handler,
+			},
+		)
+	}
+}
+
+// WithShadowComputer makes Engine execute a copy of every execution of the given master plan type
+// as its shadow using the given computer for the component with the given key. The copy is
+// executed by a separate Engine mirroring the options of this one as well as the plans and
+// computer factories registered with it. The shadow Engine reads from the CacheStore of this
+// one without writing to it and does not invoke the budget overrun and loader cancellation
+// handlers. Comparison and reporting work the same way as in WithShadowPlan.
+func WithShadowComputer(primary MasterPlan, key MetadataProvider, computer interface{}, getters []string, handler func(ShadowReport)) EngineOption {
+	return func(configs *engineConfigs) {
+		configs.addShadow(
+			primary, shadowConfigs{
+				key:      key,
+				computer: computer,
+				getters:  getters,
+				handler:  handler,
+			},
+		)
+	}
+}
+
+// WithMaxSwitchDepth sets how many switch components can nest inside one another in a single
+// execution. A switch exceeding this depth fails with a SwitchDepthError instead of executing
+// the plan it returned. The default depth is 32.
+func WithMaxSwitchDepth(depth int) EngineOption {
+	return func(configs *engineConfigs) {
+		configs.maxSwitchDepth = depth
+	}
+}
+
+func (c *engineConfigs) addShadow(primary MasterPlan, sc shadowConfigs) {
+	if c.shadows == nil {
+		c.shadows = make(map[string]shadowConfigs)
+	}
+
+	c.shadows[extractFullNameFromValue(primary)] = sc
+}
+
+func (c *engineConfigs) switchDepthLimit() int {
+	if c.maxSwitchDepth <= 0 {
+		return defaultMaxSwitchDepth
+	}
+
+	return c.maxSwitchDepth
+}
+
+func (c *engineConfigs) reportBudgetOverrun(bo BudgetOverrun) {
+	if c.budgetOverrunHandler != nil {
+		c.budgetOverrunHandler(bo)
+	}
+}
+
+func (c *engineConfigs) recordLatency(componentID string, startedAt time.Time) {
+	if c.latencies != nil {
+		c.latencies.record(componentID, startedAt)
+	}
+}
+
+func (c *engineConfigs) reportLoaderCancellation(lc LoaderCancellation) {
+	if c.cancellationHandler != nil {
+		c.cancellationHandler(lc)
+	}
+}
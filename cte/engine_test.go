@@ -0,0 +1,1298 @@
+package cte
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type engineTest_FailingComputer struct{}
+
+func (engineTest_FailingComputer) Compute(ctx context.Context, p MasterPlan) (interface{}, error) {
+	return nil, assert.AnError
+}
+
+type engineTest_SlowComputer struct{}
+
+func (engineTest_SlowComputer) Compute(ctx context.Context, p MasterPlan) (interface{}, error) {
+	select {
+	case <-time.After(20 * time.Millisecond):
+		return 1, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+type engineTest_OptionalFailure Result
+
+func (engineTest_OptionalFailure) CTEMetadata() interface{} {
+	return struct {
+		computer engineTest_FailingComputer
+		optional Optional
+	}{}
+}
+
+type engineTest_RequiredFailure Result
+
+func (engineTest_RequiredFailure) CTEMetadata() interface{} {
+	return struct {
+		computer engineTest_FailingComputer
+	}{}
+}
+
+type engineTest_SlowValue Result
+
+func (engineTest_SlowValue) CTEMetadata() interface{} {
+	return struct {
+		computer engineTest_SlowComputer
+	}{}
+}
+
+type engineTest_ParallelPlan struct {
+	Optional engineTest_OptionalFailure
+	Slow     engineTest_SlowValue
+}
+
+func (*engineTest_ParallelPlan) IsSequentialCTEPlan() bool {
+	return false
+}
+
+func (*engineTest_ParallelPlan) Execute(ctx context.Context) error {
+	return nil
+}
+
+type engineTest_SequentialPlan struct {
+	Optional engineTest_OptionalFailure
+	Slow     engineTest_SlowValue
+}
+
+func (*engineTest_SequentialPlan) IsSequentialCTEPlan() bool {
+	return true
+}
+
+func (*engineTest_SequentialPlan) Execute(ctx context.Context) error {
+	return nil
+}
+
+type engineTest_RequiredFailurePlan struct {
+	Required engineTest_RequiredFailure
+	Slow     engineTest_SlowValue
+}
+
+func (*engineTest_RequiredFailurePlan) IsSequentialCTEPlan() bool {
+	return false
+}
+
+func (*engineTest_RequiredFailurePlan) Execute(ctx context.Context) error {
+	return nil
+}
+
+func TestEngine_ExecuteMasterPlan_OptionalComponents(t *testing.T) {
+	scenarios := []struct {
+		desc string
+		test func(t *testing.T)
+	}{
+		{
+			desc: "optional failure in parallel plan does not cancel siblings",
+			test: func(t *testing.T) {
+				e := NewEngine()
+
+				p := &engineTest_ParallelPlan{}
+				e.AnalyzePlan(p)
+
+				assert.Nil(t, e.ExecuteMasterPlan(context.Background(), p))
+
+				_, err := p.Optional.Task.Outcome()
+				assert.Equal(t, assert.AnError, err)
+
+				outcome, err := p.Slow.Task.Outcome()
+				assert.Equal(t, 1, outcome)
+				assert.Nil(t, err)
+			},
+		},
+		{
+			desc: "optional failure in sequential plan does not stop execution",
+			test: func(t *testing.T) {
+				e := NewEngine()
+
+				p := &engineTest_SequentialPlan{}
+				e.AnalyzePlan(p)
+
+				assert.Nil(t, e.ExecuteMasterPlan(context.Background(), p))
+
+				_, err := p.Optional.Task.Outcome()
+				assert.Equal(t, assert.AnError, err)
+
+				outcome, err := p.Slow.Task.Outcome()
+				assert.Equal(t, 1, outcome)
+				assert.Nil(t, err)
+			},
+		},
+		{
+			desc: "required failure still fails the plan and cancels siblings",
+			test: func(t *testing.T) {
+				e := NewEngine()
+
+				p := &engineTest_RequiredFailurePlan{}
+				e.AnalyzePlan(p)
+
+				assert.Equal(t, assert.AnError, e.ExecuteMasterPlan(context.Background(), p))
+
+				_, err := p.Slow.Task.Outcome()
+				assert.Equal(t, context.Canceled, err)
+			},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		s := scenario
+
+		t.Run(s.desc, s.test)
+	}
+}
+
+type engineTest_TightBudgetPlan struct {
+	Slow engineTest_SlowValue
+}
+
+func (*engineTest_TightBudgetPlan) IsSequentialCTEPlan() bool {
+	return false
+}
+
+func (*engineTest_TightBudgetPlan) CTEBudget() time.Duration {
+	return 5 * time.Millisecond
+}
+
+type engineTest_GenerousBudgetPlan struct {
+	Nested engineTest_TightBudgetPlan
+}
+
+func (*engineTest_GenerousBudgetPlan) IsSequentialCTEPlan() bool {
+	return true
+}
+
+func (*engineTest_GenerousBudgetPlan) CTEBudget() time.Duration {
+	return time.Second
+}
+
+func (*engineTest_GenerousBudgetPlan) Execute(ctx context.Context) error {
+	return nil
+}
+
+func TestEngine_ExecuteMasterPlan_Budget(t *testing.T) {
+	var overruns []BudgetOverrun
+
+	e := NewEngine(
+		WithBudgetOverrunHandler(
+			func(bo BudgetOverrun) {
+				overruns = append(overruns, bo)
+			},
+		),
+	)
+
+	p := &engineTest_GenerousBudgetPlan{}
+	e.AnalyzePlan(p)
+
+	nestedPlanName := extractFullNameFromType(reflect.TypeOf(engineTest_TightBudgetPlan{}))
+
+	err := e.ExecuteMasterPlan(context.Background(), p)
+	assert.Equal(t, ErrPlanBudgetExceeded.Err(nestedPlanName, 5*time.Millisecond), err)
+
+	_, err = p.Nested.Slow.Task.Outcome()
+	assert.Equal(t, context.DeadlineExceeded, err)
+
+	assert.Equal(t, 1, len(overruns))
+	assert.Equal(t, nestedPlanName, overruns[0].PlanName)
+	assert.Equal(t, 5*time.Millisecond, overruns[0].Budget)
+	assert.True(t, overruns[0].Elapsed >= overruns[0].Budget)
+}
+
+type engineTest_InheritedDeadlinePlan struct {
+	Slow engineTest_SlowValue
+}
+
+func (*engineTest_InheritedDeadlinePlan) IsSequentialCTEPlan() bool {
+	return false
+}
+
+func (*engineTest_InheritedDeadlinePlan) CTEBudget() time.Duration {
+	return time.Second
+}
+
+func (*engineTest_InheritedDeadlinePlan) Execute(ctx context.Context) error {
+	return nil
+}
+
+func TestEngine_ExecuteMasterPlan_Budget_InheritedDeadline(t *testing.T) {
+	var overruns []BudgetOverrun
+
+	e := NewEngine(
+		WithBudgetOverrunHandler(
+			func(bo BudgetOverrun) {
+				overruns = append(overruns, bo)
+			},
+		),
+	)
+
+	p := &engineTest_InheritedDeadlinePlan{}
+	e.AnalyzePlan(p)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+
+	// The deadline of the request fires long before the budget of the plan
+	assert.Equal(t, context.DeadlineExceeded, e.ExecuteMasterPlan(ctx, p))
+	assert.Nil(t, overruns)
+}
+
+type engineTest_OptionalSlowValue Result
+
+func (engineTest_OptionalSlowValue) CTEMetadata() interface{} {
+	return struct {
+		computer engineTest_SlowComputer
+		optional Optional
+	}{}
+}
+
+type engineTest_OptionalSlowPlan struct {
+	Slow engineTest_OptionalSlowValue
+}
+
+func (*engineTest_OptionalSlowPlan) IsSequentialCTEPlan() bool {
+	return true
+}
+
+func (*engineTest_OptionalSlowPlan) Execute(ctx context.Context) error {
+	return nil
+}
+
+func TestEngine_ExecuteMasterPlan_DeadlineAwareSkipping(t *testing.T) {
+	e := NewEngine(WithDeadlineAwareSkipping(0.95))
+
+	e.AnalyzePlan(&engineTest_OptionalSlowPlan{})
+
+	// Without any recorded latency, the component must be executed
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	p := &engineTest_OptionalSlowPlan{}
+	assert.Nil(t, e.ExecuteMasterPlan(ctx, p))
+
+	outcome, err := p.Slow.Task.Outcome()
+	assert.Equal(t, 1, outcome)
+	assert.Nil(t, err)
+
+	// The recorded latency is now about 20ms which does not fit into a 5ms deadline
+	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+
+	p = &engineTest_OptionalSlowPlan{}
+	assert.Nil(t, e.ExecuteMasterPlan(ctx, p))
+
+	outcome, err = p.Slow.Task.Outcome()
+	assert.Nil(t, outcome)
+	assert.Equal(t, ErrComponentSkipped, err)
+}
+
+type engineTest_CacheHitComputer struct{}
+
+func (engineTest_CacheHitComputer) Compute(ctx context.Context, p MasterPlan) (interface{}, error) {
+	return nil, EndEarlyWith("cached")
+}
+
+type engineTest_CacheHit Result
+
+func (engineTest_CacheHit) CTEMetadata() interface{} {
+	return struct {
+		computer engineTest_CacheHitComputer
+	}{}
+}
+
+type engineTest_CachedPlan struct {
+	engine Engine
+	Hit    engineTest_CacheHit
+	Never  engineTest_RequiredFailure
+}
+
+func (*engineTest_CachedPlan) IsSequentialCTEPlan() bool {
+	return true
+}
+
+func (p *engineTest_CachedPlan) Execute(ctx context.Context) error {
+	return p.engine.ExecuteMasterPlan(ctx, p)
+}
+
+type engineTest_SwitchingComputer struct{}
+
+func (engineTest_SwitchingComputer) Switch(ctx context.Context, p MasterPlan) (MasterPlan, error) {
+	return &engineTest_CachedPlan{
+		engine: p.(*engineTest_SwitchingPlan).engine,
+	}, nil
+}
+
+type engineTest_Switching Result
+
+func (engineTest_Switching) CTEMetadata() interface{} {
+	return struct {
+		computer engineTest_SwitchingComputer
+	}{}
+}
+
+type engineTest_SwitchingPlan struct {
+	engine Engine
+	Branch engineTest_Switching
+}
+
+func (*engineTest_SwitchingPlan) IsSequentialCTEPlan() bool {
+	return false
+}
+
+func (p *engineTest_SwitchingPlan) Execute(ctx context.Context) error {
+	return p.engine.ExecuteMasterPlan(ctx, p)
+}
+
+func TestEngine_ExecuteMasterPlan_EndEarlyWith(t *testing.T) {
+	e := NewEngine()
+
+	e.AnalyzePlan(&engineTest_CachedPlan{})
+	e.AnalyzePlan(&engineTest_SwitchingPlan{})
+
+	t.Run(
+		"caller receives value", func(t *testing.T) {
+			ctx, eo := CaptureEarlyOutcome(context.Background())
+
+			p := &engineTest_CachedPlan{engine: e}
+			assert.Nil(t, p.Execute(ctx))
+
+			value, ok := eo.Value()
+			assert.Equal(t, "cached", value)
+			assert.True(t, ok)
+		},
+	)
+
+	t.Run(
+		"switch component receives value", func(t *testing.T) {
+			ctx, eo := CaptureEarlyOutcome(context.Background())
+
+			p := &engineTest_SwitchingPlan{engine: e}
+			assert.Nil(t, p.Execute(ctx))
+
+			outcome, err := p.Branch.Task.Outcome()
+			assert.Equal(t, "cached", outcome)
+			assert.Nil(t, err)
+
+			// The outer plan did not end early itself
+			_, ok := eo.Value()
+			assert.False(t, ok)
+		},
+	)
+}
+
+type engineTest_CountingComputer struct{}
+
+var engineTest_computeCount int32
+
+func (engineTest_CountingComputer) Compute(ctx context.Context, p MasterPlan) (interface{}, error) {
+	return atomic.AddInt32(&engineTest_computeCount, 1), nil
+}
+
+type engineTest_CachePolicy struct{}
+
+func (engineTest_CachePolicy) CacheKey(p MasterPlan) string {
+	return p.(*engineTest_CountingPlan).key
+}
+
+func (engineTest_CachePolicy) CacheTTL() time.Duration {
+	return time.Minute
+}
+
+type engineTest_Counted Result
+
+func (engineTest_Counted) CTEMetadata() interface{} {
+	return struct {
+		computer engineTest_CountingComputer
+		cache    engineTest_CachePolicy
+	}{}
+}
+
+type engineTest_CountingPlan struct {
+	key     string
+	Counted engineTest_Counted
+}
+
+func (*engineTest_CountingPlan) IsSequentialCTEPlan() bool {
+	return false
+}
+
+func (*engineTest_CountingPlan) Execute(ctx context.Context) error {
+	return nil
+}
+
+func TestEngine_ExecuteMasterPlan_Cache(t *testing.T) {
+	e := NewEngine(WithCacheStore(NewLRUCacheStore(10)))
+	e.AnalyzePlan(&engineTest_CountingPlan{})
+
+	execute := func(key string) interface{} {
+		p := &engineTest_CountingPlan{key: key}
+		assert.Nil(t, e.ExecuteMasterPlan(context.Background(), p))
+
+		outcome, err := p.Counted.Task.Outcome()
+		assert.Nil(t, err)
+
+		return outcome
+	}
+
+	first := execute("key")
+	assert.Equal(t, first, execute("key"))
+
+	// Different keys are cached separately
+	assert.NotEqual(t, first, execute("other"))
+
+	// Empty keys skip the cache
+	assert.NotEqual(t, execute(""), execute(""))
+}
+
+type engineTest_SideEffectComputer struct{}
+
+func (engineTest_SideEffectComputer) Compute(ctx context.Context, p MasterPlan) error {
+	return nil
+}
+
+type engineTest_CachedSideEffect SideEffect
+
+func (engineTest_CachedSideEffect) CTEMetadata() interface{} {
+	return struct {
+		computer engineTest_SideEffectComputer
+		cache    engineTest_CachePolicy
+	}{}
+}
+
+type engineTest_CachedSideEffectPlan struct {
+	Effect engineTest_CachedSideEffect
+}
+
+func (*engineTest_CachedSideEffectPlan) IsSequentialCTEPlan() bool {
+	return false
+}
+
+func (*engineTest_CachedSideEffectPlan) Execute(ctx context.Context) error {
+	return nil
+}
+
+func TestEngine_AnalyzePlan_CachedSideEffect(t *testing.T) {
+	e := NewEngine(WithCacheStore(NewLRUCacheStore(10)))
+
+	assert.PanicsWithError(
+		t, ErrCachePolicyOnSideEffect.Err(
+			reflect.TypeOf(engineTest_CachePolicy{}),
+			reflect.TypeOf(&engineTest_CachedSideEffect{}),
+			reflect.TypeOf(&engineTest_SideEffectComputer{}),
+		).Error(), func() {
+			e.AnalyzePlan(&engineTest_CachedSideEffectPlan{})
+		},
+	)
+}
+
+type engineTest_DeduplicatedComputer struct{}
+
+func (engineTest_DeduplicatedComputer) Load(ctx context.Context, p MasterPlan) (interface{}, error) {
+	casted := p.(*engineTest_DeduplicatedPlan)
+
+	<-casted.release
+	return atomic.AddInt32(casted.loadCount, 1), nil
+}
+
+func (engineTest_DeduplicatedComputer) LoadKey(p MasterPlan) string {
+	return "configs"
+}
+
+func (engineTest_DeduplicatedComputer) Compute(ctx context.Context, p MasterPlan, data LoadingData) (interface{}, error) {
+	return data.Data, data.Err
+}
+
+type engineTest_Deduplicated Result
+
+func (engineTest_Deduplicated) CTEMetadata() interface{} {
+	return struct {
+		computer engineTest_DeduplicatedComputer
+	}{}
+}
+
+type engineTest_DeduplicatedPlan struct {
+	release   chan struct{}
+	loadCount *int32
+	Loaded    engineTest_Deduplicated
+}
+
+func (*engineTest_DeduplicatedPlan) IsSequentialCTEPlan() bool {
+	return true
+}
+
+func (*engineTest_DeduplicatedPlan) Execute(ctx context.Context) error {
+	return nil
+}
+
+func TestEngine_ExecuteMasterPlan_DeduplicatedLoads(t *testing.T) {
+	group := &deduplicateLoads_Group{
+		joined: make(chan struct{}, 5),
+	}
+
+	e := NewEngine()
+	e.loads = group
+	e.AnalyzePlan(&engineTest_DeduplicatedPlan{})
+
+	var loadCount int32
+	release := make(chan struct{})
+
+	var wg sync.WaitGroup
+	plans := make([]*engineTest_DeduplicatedPlan, 5)
+	for i := range plans {
+		p := &engineTest_DeduplicatedPlan{
+			release:   release,
+			loadCount: &loadCount,
+		}
+
+		plans[i] = p
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			assert.Nil(t, e.ExecuteMasterPlan(context.Background(), p))
+		}()
+	}
+
+	// Wait for all executions to join the in-flight load
+	for range plans {
+		<-group.joined
+	}
+
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&loadCount))
+	for _, p := range plans {
+		outcome, err := p.Loaded.Task.Outcome()
+		assert.Equal(t, int32(1), outcome)
+		assert.Nil(t, err)
+	}
+}
+
+type engineTest_SignallingComputer struct{}
+
+func (engineTest_SignallingComputer) Compute(ctx context.Context, p MasterPlan) (interface{}, error) {
+	close(p.(*engineTest_StreamingPlan).firstComputed)
+	return nil, nil
+}
+
+type engineTest_Signalling Result
+
+func (engineTest_Signalling) CTEMetadata() interface{} {
+	return struct {
+		computer engineTest_SignallingComputer
+	}{}
+}
+
+type engineTest_WaitingLoaderComputer struct{}
+
+func (engineTest_WaitingLoaderComputer) Load(ctx context.Context, p MasterPlan) (interface{}, error) {
+	select {
+	case <-p.(*engineTest_StreamingPlan).firstComputed:
+		return true, nil
+	case <-time.After(100 * time.Millisecond):
+		return false, nil
+	}
+}
+
+func (engineTest_WaitingLoaderComputer) Compute(ctx context.Context, p MasterPlan, data LoadingData) (interface{}, error) {
+	return data.Data, data.Err
+}
+
+type engineTest_WaitingLoader Result
+
+func (engineTest_WaitingLoader) CTEMetadata() interface{} {
+	return struct {
+		computer engineTest_WaitingLoaderComputer
+	}{}
+}
+
+type engineTest_StreamingPlan struct {
+	firstComputed chan struct{}
+	First         engineTest_Signalling
+	Second        engineTest_WaitingLoader
+}
+
+func (*engineTest_StreamingPlan) IsSequentialCTEPlan() bool {
+	return true
+}
+
+func (*engineTest_StreamingPlan) Execute(ctx context.Context) error {
+	return nil
+}
+
+func TestEngine_ExecuteMasterPlan_StreamingLoaders(t *testing.T) {
+	e := NewEngine()
+	e.AnalyzePlan(&engineTest_StreamingPlan{})
+
+	p := &engineTest_StreamingPlan{
+		firstComputed: make(chan struct{}),
+	}
+
+	assert.Nil(t, e.ExecuteMasterPlan(context.Background(), p))
+
+	// The first component must not wait for the loader of the second one
+	outcome, err := p.Second.Task.Outcome()
+	assert.Equal(t, true, outcome)
+	assert.Nil(t, err)
+}
+
+type engineTest_BlockingLoaderComputer struct{}
+
+func (engineTest_BlockingLoaderComputer) Load(ctx context.Context, p MasterPlan) (interface{}, error) {
+	close(p.(*engineTest_FailingEarlyPlan).loaderStarted)
+
+	<-ctx.Done()
+	close(p.(*engineTest_FailingEarlyPlan).loaderCancelled)
+
+	return nil, ctx.Err()
+}
+
+func (engineTest_BlockingLoaderComputer) Compute(ctx context.Context, p MasterPlan, data LoadingData) (interface{}, error) {
+	return data.Data, data.Err
+}
+
+type engineTest_BlockingLoader Result
+
+func (engineTest_BlockingLoader) CTEMetadata() interface{} {
+	return struct {
+		computer engineTest_BlockingLoaderComputer
+	}{}
+}
+
+type engineTest_FailingAfterLoadComputer struct{}
+
+func (engineTest_FailingAfterLoadComputer) Compute(ctx context.Context, p MasterPlan) (interface{}, error) {
+	<-p.(*engineTest_FailingEarlyPlan).loaderStarted
+	return nil, assert.AnError
+}
+
+type engineTest_FailingAfterLoad Result
+
+func (engineTest_FailingAfterLoad) CTEMetadata() interface{} {
+	return struct {
+		computer engineTest_FailingAfterLoadComputer
+	}{}
+}
+
+type engineTest_FailingEarlyPlan struct {
+	loaderStarted   chan struct{}
+	loaderCancelled chan struct{}
+	Required        engineTest_FailingAfterLoad
+	Blocked         engineTest_BlockingLoader
+}
+
+func (*engineTest_FailingEarlyPlan) IsSequentialCTEPlan() bool {
+	return true
+}
+
+func (*engineTest_FailingEarlyPlan) Execute(ctx context.Context) error {
+	return nil
+}
+
+func TestEngine_ExecuteMasterPlan_CancelOutstandingLoaders(t *testing.T) {
+	var cancellations []LoaderCancellation
+
+	e := NewEngine(
+		WithLoaderCancellationHandler(
+			func(lc LoaderCancellation) {
+				cancellations = append(cancellations, lc)
+			},
+		),
+	)
+
+	e.AnalyzePlan(&engineTest_FailingEarlyPlan{})
+
+	p := &engineTest_FailingEarlyPlan{
+		loaderStarted:   make(chan struct{}),
+		loaderCancelled: make(chan struct{}),
+	}
+
+	assert.Equal(t, assert.AnError, e.ExecuteMasterPlan(context.Background(), p))
+
+	select {
+	case <-p.loaderCancelled:
+	case <-time.After(time.Second):
+		assert.Fail(t, "loader was not cancelled")
+	}
+
+	assert.Equal(
+		t, []LoaderCancellation{
+			{
+				PlanName: extractFullNameFromValue(p),
+				Count:    1,
+			},
+		}, cancellations,
+	)
+}
+
+type engineTest_SkippedLoaderComputer struct{}
+
+func (engineTest_SkippedLoaderComputer) Load(ctx context.Context, p MasterPlan) (interface{}, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (engineTest_SkippedLoaderComputer) Compute(ctx context.Context, p MasterPlan, data LoadingData) (interface{}, error) {
+	return data.Data, data.Err
+}
+
+type engineTest_SkippedLoader Result
+
+func (engineTest_SkippedLoader) CTEMetadata() interface{} {
+	return struct {
+		computer engineTest_SkippedLoaderComputer
+		optional Optional
+	}{}
+}
+
+type engineTest_SkippingPlan struct {
+	Skipped engineTest_SkippedLoader
+}
+
+func (*engineTest_SkippingPlan) IsSequentialCTEPlan() bool {
+	return true
+}
+
+func (*engineTest_SkippingPlan) Execute(ctx context.Context) error {
+	return nil
+}
+
+func TestEngine_ExecuteMasterPlan_CancelOutstandingLoaders_SkippedComponents(t *testing.T) {
+	var cancellations []LoaderCancellation
+
+	e := NewEngine(
+		WithDeadlineAwareSkipping(0.95),
+		WithLoaderCancellationHandler(
+			func(lc LoaderCancellation) {
+				cancellations = append(cancellations, lc)
+			},
+		),
+	)
+
+	e.AnalyzePlan(&engineTest_SkippingPlan{})
+
+	// Pretend the component recently took far longer than the deadline
+	w := &latencyWindow{
+		count:  1,
+		next:   1,
+		lastAt: time.Now(),
+	}
+
+	w.durations[0] = time.Hour
+	e.configs.latencies.windows[extractFullNameFromValue(engineTest_SkippedLoader{})] = w
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	p := &engineTest_SkippingPlan{}
+	assert.Nil(t, e.ExecuteMasterPlan(ctx, p))
+
+	_, err := p.Skipped.Task.Outcome()
+	assert.Equal(t, ErrComponentSkipped, err)
+
+	// The plan succeeded, hence there is nothing to report
+	assert.Nil(t, cancellations)
+}
+
+type engineTest_WaitingForNestedLoaderComputer struct{}
+
+func (engineTest_WaitingForNestedLoaderComputer) Compute(ctx context.Context, p MasterPlan) (interface{}, error) {
+	select {
+	case <-p.(*engineTest_HoistingPlan).nestedLoaderStarted:
+		return true, nil
+	case <-time.After(50 * time.Millisecond):
+		return false, nil
+	}
+}
+
+type engineTest_WaitingForNestedLoader Result
+
+func (engineTest_WaitingForNestedLoader) CTEMetadata() interface{} {
+	return struct {
+		computer engineTest_WaitingForNestedLoaderComputer
+	}{}
+}
+
+type engineTest_SignallingLoaderComputer struct{}
+
+func (engineTest_SignallingLoaderComputer) Load(ctx context.Context, p MasterPlan) (interface{}, error) {
+	close(p.(*engineTest_HoistingPlan).nestedLoaderStarted)
+	return 1, nil
+}
+
+func (engineTest_SignallingLoaderComputer) Compute(ctx context.Context, p MasterPlan, data LoadingData) (interface{}, error) {
+	return data.Data, data.Err
+}
+
+type engineTest_SignallingLoader Result
+
+func (engineTest_SignallingLoader) CTEMetadata() interface{} {
+	return struct {
+		computer engineTest_SignallingLoaderComputer
+	}{}
+}
+
+type engineTest_NestedLoadingPlan struct {
+	Loaded engineTest_SignallingLoader
+}
+
+func (*engineTest_NestedLoadingPlan) IsSequentialCTEPlan() bool {
+	return true
+}
+
+type engineTest_HoistingPlan struct {
+	nestedLoaderStarted chan struct{}
+	First               engineTest_WaitingForNestedLoader
+	Nested              engineTest_NestedLoadingPlan
+}
+
+func (*engineTest_HoistingPlan) IsSequentialCTEPlan() bool {
+	return true
+}
+
+func (*engineTest_HoistingPlan) Execute(ctx context.Context) error {
+	return nil
+}
+
+func TestEngine_ExecuteMasterPlan_LoaderHoisting(t *testing.T) {
+	scenarios := []struct {
+		desc                           string
+		options                        []EngineOption
+		expectNestedLoaderStartedFirst bool
+	}{
+		{
+			desc:                           "without hoisting",
+			expectNestedLoaderStartedFirst: false,
+		},
+		{
+			desc:                           "with hoisting",
+			options:                        []EngineOption{WithLoaderHoisting()},
+			expectNestedLoaderStartedFirst: true,
+		},
+	}
+
+	for _, scenario := range scenarios {
+		s := scenario
+
+		t.Run(
+			s.desc, func(t *testing.T) {
+				e := NewEngine(s.options...)
+				e.AnalyzePlan(&engineTest_HoistingPlan{})
+
+				p := &engineTest_HoistingPlan{
+					nestedLoaderStarted: make(chan struct{}),
+				}
+
+				assert.Nil(t, e.ExecuteMasterPlan(context.Background(), p))
+
+				outcome, err := p.First.Task.Outcome()
+				assert.Equal(t, s.expectNestedLoaderStartedFirst, outcome)
+				assert.Nil(t, err)
+
+				// The nested component still receives its data
+				outcome, err = p.Nested.Loaded.Task.Outcome()
+				assert.Equal(t, 1, outcome)
+				assert.Nil(t, err)
+			},
+		)
+	}
+}
+
+type engineTest_HangingLoaderComputer struct{}
+
+func (engineTest_HangingLoaderComputer) Load(ctx context.Context, p MasterPlan) (interface{}, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-time.After(time.Second):
+		return "late", nil
+	}
+}
+
+func (engineTest_HangingLoaderComputer) Compute(ctx context.Context, p MasterPlan, data LoadingData) (interface{}, error) {
+	return data.Data, data.Err
+}
+
+type engineTest_HangingLoader Result
+
+func (engineTest_HangingLoader) CTEMetadata() interface{} {
+	return struct {
+		computer engineTest_HangingLoaderComputer
+	}{}
+}
+
+type engineTest_BudgetedLoadingPlan struct {
+	Hanging engineTest_HangingLoader
+}
+
+func (*engineTest_BudgetedLoadingPlan) IsSequentialCTEPlan() bool {
+	return true
+}
+
+func (*engineTest_BudgetedLoadingPlan) CTEBudget() time.Duration {
+	return 10 * time.Millisecond
+}
+
+type engineTest_HoistingBudgetPlan struct {
+	Nested engineTest_BudgetedLoadingPlan
+}
+
+func (*engineTest_HoistingBudgetPlan) IsSequentialCTEPlan() bool {
+	return true
+}
+
+func (*engineTest_HoistingBudgetPlan) Execute(ctx context.Context) error {
+	return nil
+}
+
+func TestEngine_ExecuteMasterPlan_LoaderHoisting_NestedBudget(t *testing.T) {
+	e := NewEngine(WithLoaderHoisting())
+	e.AnalyzePlan(&engineTest_HoistingBudgetPlan{})
+
+	nestedPlanName := extractFullNameFromValue(engineTest_BudgetedLoadingPlan{})
+
+	startedAt := time.Now()
+
+	p := &engineTest_HoistingBudgetPlan{}
+	assert.Equal(t, ErrPlanBudgetExceeded.Err(nestedPlanName, 10*time.Millisecond), e.ExecuteMasterPlan(context.Background(), p))
+
+	// The hoisted loader is bound to the budget of its nested plan
+	assert.Less(t, int64(time.Since(startedAt)), int64(500*time.Millisecond))
+}
+
+type engineTest_EndingComputer struct{}
+
+func (engineTest_EndingComputer) Compute(ctx context.Context, p MasterPlan) (interface{}, error) {
+	return nil, ErrPlanExecutionEndingEarly
+}
+
+type engineTest_Ending Result
+
+func (engineTest_Ending) CTEMetadata() interface{} {
+	return struct {
+		computer engineTest_EndingComputer
+	}{}
+}
+
+type engineTest_SlowLoaderComputer struct{}
+
+func (engineTest_SlowLoaderComputer) Load(ctx context.Context, p MasterPlan) (interface{}, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-time.After(20 * time.Millisecond):
+		return "shared", nil
+	}
+}
+
+func (engineTest_SlowLoaderComputer) Compute(ctx context.Context, p MasterPlan, data LoadingData) (interface{}, error) {
+	return data.Data, data.Err
+}
+
+type engineTest_SharedLoader Result
+
+func (engineTest_SharedLoader) CTEMetadata() interface{} {
+	return struct {
+		computer engineTest_SlowLoaderComputer
+	}{}
+}
+
+type engineTest_EndingEarlyPlan struct {
+	Ending engineTest_Ending
+	Shared engineTest_SharedLoader
+}
+
+func (*engineTest_EndingEarlyPlan) IsSequentialCTEPlan() bool {
+	return true
+}
+
+type engineTest_SharingPlan struct {
+	Shared engineTest_SharedLoader
+}
+
+func (*engineTest_SharingPlan) IsSequentialCTEPlan() bool {
+	return true
+}
+
+type engineTest_HoistingSharedPlan struct {
+	First  engineTest_EndingEarlyPlan
+	Second engineTest_SharingPlan
+}
+
+func (*engineTest_HoistingSharedPlan) IsSequentialCTEPlan() bool {
+	return true
+}
+
+func (*engineTest_HoistingSharedPlan) Execute(ctx context.Context) error {
+	return nil
+}
+
+func TestEngine_ExecuteMasterPlan_LoaderHoisting_SharedComponent(t *testing.T) {
+	var cancellations []LoaderCancellation
+
+	e := NewEngine(
+		WithLoaderHoisting(),
+		WithLoaderCancellationHandler(
+			func(lc LoaderCancellation) {
+				cancellations = append(cancellations, lc)
+			},
+		),
+	)
+
+	e.AnalyzePlan(&engineTest_HoistingSharedPlan{})
+
+	p := &engineTest_HoistingSharedPlan{}
+	assert.Nil(t, e.ExecuteMasterPlan(context.Background(), p))
+
+	// The first nested plan ending early must not cancel the data the second one still needs
+	outcome, err := p.Second.Shared.Task.Outcome()
+	assert.Equal(t, "shared", outcome)
+	assert.Nil(t, err)
+
+	assert.Nil(t, cancellations)
+}
+
+type engineTest_FailingLoaderComputer struct{}
+
+func (engineTest_FailingLoaderComputer) Load(ctx context.Context, p MasterPlan) (interface{}, error) {
+	return nil, assert.AnError
+}
+
+func (engineTest_FailingLoaderComputer) Compute(ctx context.Context, p MasterPlan, data LoadingData) (interface{}, error) {
+	return "computed", nil
+}
+
+type engineTest_PassThroughLoaderError Result
+
+func (engineTest_PassThroughLoaderError) CTEMetadata() interface{} {
+	return struct {
+		computer      engineTest_FailingLoaderComputer
+		onLoaderError PassThroughLoaderError
+	}{}
+}
+
+type engineTest_FailComponentOnLoaderError Result
+
+func (engineTest_FailComponentOnLoaderError) CTEMetadata() interface{} {
+	return struct {
+		computer      engineTest_FailingLoaderComputer
+		optional      Optional
+		onLoaderError FailComponentOnLoaderError
+	}{}
+}
+
+type engineTest_FailPlanOnLoaderError Result
+
+func (engineTest_FailPlanOnLoaderError) CTEMetadata() interface{} {
+	return struct {
+		computer      engineTest_FailingLoaderComputer
+		optional      Optional
+		onLoaderError FailPlanOnLoaderError
+	}{}
+}
+
+type engineTest_PassThroughLoaderErrorPlan struct {
+	Loaded engineTest_PassThroughLoaderError
+}
+
+func (*engineTest_PassThroughLoaderErrorPlan) IsSequentialCTEPlan() bool {
+	return true
+}
+
+func (*engineTest_PassThroughLoaderErrorPlan) Execute(ctx context.Context) error {
+	return nil
+}
+
+type engineTest_FailComponentOnLoaderErrorPlan struct {
+	Loaded engineTest_FailComponentOnLoaderError
+}
+
+func (*engineTest_FailComponentOnLoaderErrorPlan) IsSequentialCTEPlan() bool {
+	return false
+}
+
+func (*engineTest_FailComponentOnLoaderErrorPlan) Execute(ctx context.Context) error {
+	return nil
+}
+
+type engineTest_FailPlanOnLoaderErrorPlan struct {
+	Loaded engineTest_FailPlanOnLoaderError
+}
+
+func (*engineTest_FailPlanOnLoaderErrorPlan) IsSequentialCTEPlan() bool {
+	return false
+}
+
+func (*engineTest_FailPlanOnLoaderErrorPlan) Execute(ctx context.Context) error {
+	return nil
+}
+
+func TestEngine_ExecuteMasterPlan_LoaderErrorPolicy(t *testing.T) {
+	e := NewEngine()
+	e.AnalyzePlan(&engineTest_PassThroughLoaderErrorPlan{})
+	e.AnalyzePlan(&engineTest_FailComponentOnLoaderErrorPlan{})
+	e.AnalyzePlan(&engineTest_FailPlanOnLoaderErrorPlan{})
+
+	scenarios := []struct {
+		desc string
+		test func(t *testing.T)
+	}{
+		{
+			desc: "pass through lets computer handle the error",
+			test: func(t *testing.T) {
+				p := &engineTest_PassThroughLoaderErrorPlan{}
+
+				assert.Nil(t, e.ExecuteMasterPlan(context.Background(), p))
+
+				outcome, err := p.Loaded.Task.Outcome()
+				assert.Equal(t, "computed", outcome)
+				assert.Nil(t, err)
+			},
+		},
+		{
+			desc: "fail component skips computer and respects optional",
+			test: func(t *testing.T) {
+				p := &engineTest_FailComponentOnLoaderErrorPlan{}
+
+				assert.Nil(t, e.ExecuteMasterPlan(context.Background(), p))
+
+				outcome, err := p.Loaded.Task.Outcome()
+				assert.Nil(t, outcome)
+				assert.Equal(t, assert.AnError, err)
+			},
+		},
+		{
+			desc: "fail plan overrides optional",
+			test: func(t *testing.T) {
+				p := &engineTest_FailPlanOnLoaderErrorPlan{}
+
+				err := e.ExecuteMasterPlan(context.Background(), p)
+				assert.True(t, errors.Is(err, assert.AnError))
+
+				outcome, _ := p.Loaded.Task.Outcome()
+				assert.Nil(t, outcome)
+			},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		sc := scenario
+		t.Run(sc.desc, sc.test)
+	}
+}
+
+type engineTest_GreetingComputer struct {
+	greeting string
+}
+
+func (c engineTest_GreetingComputer) Load(ctx context.Context, p MasterPlan) (interface{}, error) {
+	return c.greeting, nil
+}
+
+func (c engineTest_GreetingComputer) Compute(ctx context.Context, p MasterPlan, data LoadingData) (interface{}, error) {
+	return data.Data.(string) + " " + c.greeting, nil
+}
+
+type engineTest_Greeting Result
+
+func (engineTest_Greeting) CTEMetadata() interface{} {
+	return struct {
+		computer engineTest_GreetingComputer
+	}{}
+}
+
+type engineTest_GreetingPlan struct {
+	Greeting engineTest_Greeting
+}
+
+func (*engineTest_GreetingPlan) IsSequentialCTEPlan() bool {
+	return true
+}
+
+func (*engineTest_GreetingPlan) Execute(ctx context.Context) error {
+	return nil
+}
+
+func TestEngine_RegisterComputerFactory(t *testing.T) {
+	scenarios := []struct {
+		desc string
+		test func(t *testing.T)
+	}{
+		{
+			desc: "factory registered before analyzing plan",
+			test: func(t *testing.T) {
+				e := NewEngine()
+				e.RegisterComputerFactory(
+					engineTest_Greeting{}, func() interface{} {
+						return engineTest_GreetingComputer{greeting: "hello"}
+					},
+				)
+				e.AnalyzePlan(&engineTest_GreetingPlan{})
+
+				p := &engineTest_GreetingPlan{}
+				assert.Nil(t, e.ExecuteMasterPlan(context.Background(), p))
+
+				outcome, err := p.Greeting.Task.Outcome()
+				assert.Equal(t, "hello hello", outcome)
+				assert.Nil(t, err)
+			},
+		},
+		{
+			desc: "factory registered after analyzing plan replaces computer and loader",
+			test: func(t *testing.T) {
+				e := NewEngine()
+				e.AnalyzePlan(&engineTest_GreetingPlan{})
+				e.RegisterComputerFactory(
+					engineTest_Greeting{}, func() interface{} {
+						return &engineTest_GreetingComputer{greeting: "hi"}
+					},
+				)
+
+				p := &engineTest_GreetingPlan{}
+				assert.Nil(t, e.ExecuteMasterPlan(context.Background(), p))
+
+				outcome, err := p.Greeting.Task.Outcome()
+				assert.Equal(t, "hi hi", outcome)
+				assert.Nil(t, err)
+			},
+		},
+		{
+			desc: "factory returning a different type panics",
+			test: func(t *testing.T) {
+				e := NewEngine()
+				e.RegisterComputerFactory(
+					engineTest_Greeting{}, func() interface{} {
+						return engineTest_SlowComputer{}
+					},
+				)
+
+				assert.Panics(
+					t, func() {
+						e.AnalyzePlan(&engineTest_GreetingPlan{})
+					},
+				)
+			},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		sc := scenario
+		t.Run(sc.desc, sc.test)
+	}
+}
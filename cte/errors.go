@@ -3,6 +3,7 @@ package cte
 import (
 	"errors"
 	"fmt"
+	"strings"
 )
 
 type formatErr struct {
@@ -34,7 +35,16 @@ var (
 	// and then return a nil error to clients.
 	//
 	// Note: If the ending plan is nested inside another plan, the outer plan will also end.
+	// Use EndEarlyWith instead to supply the final outcome of the plan at the same time.
 	ErrRootPlanExecutionEndingEarly = errors.New("CTE-0002: plan execution ending early from root")
+	// ErrComponentSkipped is set in the Result of an optional component that the engine chose
+	// not to execute because it was not expected to complete before the context deadline.
+	ErrComponentSkipped = errors.New("CTE-0016: component skipped as it could not complete before the deadline")
+	// ErrEngineShuttingDown is returned when executing a plan after Engine.Shutdown was called.
+	ErrEngineShuttingDown = errors.New("CTE-0022: engine is shutting down")
+	// ErrExperimentNotConfigured is returned by an ExperimentSwitch that was not created using
+	// NewExperimentSwitch, typically because no factory was registered for its component.
+	ErrExperimentNotConfigured = errors.New("CTE-0029: experiment switch is not configured, register it using RegisterComputerFactory")
 
 	ErrPlanMustUsePointerReceiver = makeFormatErr("CTE-0003: %v is using value receiver, all plans must be implemented using pointer receiver")
 	ErrPlanNotAnalyzed            = makeFormatErr("CTE-0004: %v has not been analyzed yet, call AnalyzePlan on it first")
@@ -55,4 +65,60 @@ var (
 	ErrParallelPlanCannotContainSyncResult     = makeFormatErr("CTE-0012: parallel plan [%v] cannot contain SyncResult field [%v]")
 	ErrParallelPlanCannotContainSyncSideEffect = makeFormatErr("CTE-0013: parallel plan [%v] cannot contain SyncSideEffect field [%v]")
 	ErrUnknownComputerKeyType                  = makeFormatErr("CTE-0014: plan [%v] contains unknown computer key type [%v]")
+
+	ErrPlanBudgetExceeded = makeFormatErr("CTE-0015: plan [%v] did not complete within its time budget of %v")
+	ErrInvalidCachePolicy = makeFormatErr("CTE-0017: cache meta %v in %v does not implement the CachePolicy interface")
+	ErrBatchSizeMismatch  = makeFormatErr("CTE-0018: batch loaded %v keys but returned %v results")
+
+	ErrInvalidLoaderErrorPolicy = makeFormatErr("CTE-0020: onLoaderError meta %v in %v is not a known loader error policy")
+	ErrFactoryTypeMismatch      = makeFormatErr("CTE-0021: factory returned %v but computer meta in %v is %v")
+
+	ErrInitFailed  = makeFormatErr("CTE-0023: failed to initialize %v: %w")
+	ErrCloseFailed = makeFormatErr("CTE-0024: failed to close %v: %w")
+
+	ErrUnknownMetaKey          = makeFormatErr("CTE-0025: metadata of %v declares unknown key [%v], valid keys are %v")
+	ErrComputerMetaNotConcrete = makeFormatErr("CTE-0026: computer meta %v in %v must be a concrete type")
+	ErrInoutMetaNotInterface   = makeFormatErr("CTE-0027: inout meta %v in %v must be an interface")
+
+	ErrMetaKeyAlreadyRegistered = makeFormatErr("CTE-0028: metadata key [%v] is already registered")
+	ErrInvalidExperiment        = makeFormatErr("CTE-0030: experiment [%v] is invalid: %v")
+
+	ErrInvalidSwitchTargets = makeFormatErr("CTE-0031: switchTargets meta %v in %v must be a struct whose fields are plans")
+	ErrSwitchCycle          = makeFormatErr("CTE-0032: switch targets form a cycle: [%v]")
+
+	ErrCachePolicyOnSideEffect = makeFormatErr("CTE-0034: cache meta %v in %v cannot be used with side effect computer %v")
 )
+
+// loaderFailure is returned by components whose loader error must fail the whole plan
+// regardless of whether they are optional.
+type loaderFailure struct {
+	componentID string
+	err         error
+}
+
+func (e loaderFailure) Error() string {
+	return fmt.Sprintf("CTE-0019: loader of [%v] failed: %v", extractShortName(e.componentID), e.err)
+}
+
+func (e loaderFailure) Unwrap() error {
+	return e.err
+}
+
+// SwitchDepthError is returned when a chain of switch components nests deeper than the limit
+// configured via WithMaxSwitchDepth, typically because the switched plans loop back on each
+// other.
+type SwitchDepthError struct {
+	// Chain contains the names of the plans in the order they were switched into, starting
+	// with the plan containing the first switch component.
+	Chain    []string
+	MaxDepth int
+}
+
+func (e SwitchDepthError) Error() string {
+	shortNames := make([]string, 0, len(e.Chain))
+	for _, planName := range e.Chain {
+		shortNames = append(shortNames, extractShortName(planName))
+	}
+
+	return fmt.Sprintf("CTE-0033: switch depth exceeded the limit of %v, chain: [%v]", e.MaxDepth, strings.Join(shortNames, " -> "))
+}
@@ -0,0 +1,62 @@
+package cte
+
+import (
+	"context"
+)
+
+// componentObserver gets notified by Engine as the components of an execution start and complete.
+// Both methods may be invoked concurrently by components executing in parallel and receive
+// the context of the component, which observers blocking on anything must respect.
+type componentObserver interface {
+	componentStarted(ctx context.Context, componentID string)
+	componentCompleted(ctx context.Context, componentID string, value interface{}, err error)
+}
+
+type componentObserversKey struct{}
+
+// withComponentObserver returns a new context carrying the given observer on top of the ones
+// already attached to the given context.
+func withComponentObserver(ctx context.Context, o componentObserver) context.Context {
+	existing := extractComponentObservers(ctx)
+
+	observers := make([]componentObserver, 0, len(existing)+1)
+	observers = append(observers, existing...)
+	observers = append(observers, o)
+
+	return context.WithValue(ctx, componentObserversKey{}, observers)
+}
+
+func extractComponentObservers(ctx context.Context) []componentObserver {
+	observers, _ := ctx.Value(componentObserversKey{}).([]componentObserver)
+	return observers
+}
+
+func notifyComponentStarted(ctx context.Context, componentID string) {
+	observers := extractComponentObservers(ctx)
+	if len(observers) == 0 {
+		return
+	}
+
+	WhenKept(
+		ctx, func() {
+			for _, o := range observers {
+				o.componentStarted(ctx, componentID)
+			}
+		},
+	)
+}
+
+func notifyComponentCompleted(ctx context.Context, componentID string, value interface{}, err error) {
+	observers := extractComponentObservers(ctx)
+	if len(observers) == 0 {
+		return
+	}
+
+	WhenKept(
+		ctx, func() {
+			for _, o := range observers {
+				o.componentCompleted(ctx, componentID, value, err)
+			}
+		},
+	)
+}
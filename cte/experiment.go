@@ -0,0 +1,114 @@
+package cte
+
+import (
+	"context"
+	"hash/fnv"
+)
+
+// Variant is one of the plans that ExperimentSwitch can choose.
+type Variant struct {
+	Name string
+	// Weight is the share of traffic routed to this variant relative to the other variants.
+	Weight uint32
+	// NewPlan creates the plan to switch to from the plan being executed.
+	NewPlan func(p MasterPlan) MasterPlan
+}
+
+// VariantAssignment describes the variant that ExperimentSwitch chose for an execution.
+type VariantAssignment struct {
+	Experiment string
+	Variant    string
+	Key        string
+}
+
+// ExperimentSwitch is a SwitchComputer that routes every execution to one of several variants
+// using weighted bucketing on a key derived from the plan, e.g. a user ID. The same key is
+// always routed to the same variant as long as the variants and their weights do not change.
+//
+// To use it, declare ExperimentSwitch under the `computer` key in CTEMetadata and provide the
+// configured instance via Engine.RegisterComputerFactory.
+type ExperimentSwitch struct {
+	name        string
+	keyFn       func(p MasterPlan) string
+	variants    []Variant
+	totalWeight uint32
+	handler     func(ctx context.Context, va VariantAssignment)
+}
+
+type ExperimentOption func(*ExperimentSwitch)
+
+// WithVariantAssignmentHandler sets the handler that ExperimentSwitch will invoke with the variant
+// chosen for every execution. The handler is invoked synchronously and hence, should return
+// quickly.
+func WithVariantAssignmentHandler(handler func(ctx context.Context, va VariantAssignment)) ExperimentOption {
+	return func(s *ExperimentSwitch) {
+		s.handler = handler
+	}
+}
+
+// NewExperimentSwitch returns an ExperimentSwitch for the experiment with the given name. The name
+// is part of the bucketing so that different experiments split the same keys independently.
+// It panics if no variant is given or if any variant has a zero weight or no NewPlan.
+func NewExperimentSwitch(name string, keyFn func(p MasterPlan) string, variants []Variant, options ...ExperimentOption) ExperimentSwitch {
+	result := ExperimentSwitch{
+		name:     name,
+		keyFn:    keyFn,
+		variants: variants,
+	}
+
+	if len(variants) == 0 {
+		panic(ErrInvalidExperiment.Err(name, "no variant"))
+	}
+
+	for _, v := range variants {
+		if v.Weight == 0 || v.NewPlan == nil {
+			panic(ErrInvalidExperiment.Err(name, "variant "+v.Name+" must have a positive weight and a NewPlan"))
+		}
+
+		result.totalWeight += v.Weight
+	}
+
+	for _, o := range options {
+		o(&result)
+	}
+
+	return result
+}
+
+func (s ExperimentSwitch) Switch(ctx context.Context, p MasterPlan) (MasterPlan, error) {
+	if len(s.variants) == 0 {
+		return nil, ErrExperimentNotConfigured
+	}
+
+	key := s.keyFn(p)
+	v := s.assign(key)
+
+	if s.handler != nil {
+		s.handler(
+			ctx, VariantAssignment{
+				Experiment: s.name,
+				Variant:    v.Name,
+				Key:        key,
+			},
+		)
+	}
+
+	return v.NewPlan(p), nil
+}
+
+func (s ExperimentSwitch) assign(key string) Variant {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(s.name + ":" + key))
+
+	bucket := h.Sum32() % s.totalWeight
+	for _, v := range s.variants {
+		if bucket < v.Weight {
+			return v
+		}
+
+		bucket -= v.Weight
+	}
+
+	// Unreachable since bucket is always less than the total weight
+	return s.variants[len(s.variants)-1]
+}
@@ -0,0 +1,148 @@
+package cte
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type experimentTest_Experiment Result
+
+func (experimentTest_Experiment) CTEMetadata() interface{} {
+	return struct {
+		computer ExperimentSwitch
+	}{}
+}
+
+type experimentTest_Plan struct {
+	engine     Engine
+	userID     string
+	Experiment experimentTest_Experiment
+}
+
+func (*experimentTest_Plan) IsSequentialCTEPlan() bool {
+	return true
+}
+
+func (p *experimentTest_Plan) Execute(ctx context.Context) error {
+	return p.engine.ExecuteMasterPlan(ctx, p)
+}
+
+type experimentTest_VariantComputer struct{}
+
+func (experimentTest_VariantComputer) Compute(ctx context.Context, p MasterPlan) (interface{}, error) {
+	return "variant", nil
+}
+
+type experimentTest_VariantValue SyncResult
+
+func (experimentTest_VariantValue) CTEMetadata() interface{} {
+	return struct {
+		computer experimentTest_VariantComputer
+	}{}
+}
+
+type experimentTest_VariantPlan struct {
+	engine Engine
+	Value  experimentTest_VariantValue
+}
+
+func (*experimentTest_VariantPlan) IsSequentialCTEPlan() bool {
+	return true
+}
+
+func (p *experimentTest_VariantPlan) Execute(ctx context.Context) error {
+	return p.engine.ExecuteMasterPlan(ctx, p)
+}
+
+func TestExperimentSwitch(t *testing.T) {
+	var assignments []VariantAssignment
+	var switchedTo *experimentTest_VariantPlan
+
+	newPlan := func(p MasterPlan) MasterPlan {
+		switchedTo = &experimentTest_VariantPlan{
+			engine: p.(*experimentTest_Plan).engine,
+		}
+
+		return switchedTo
+	}
+
+	e := NewEngine()
+	e.RegisterComputerFactory(
+		experimentTest_Experiment{}, func() interface{} {
+			return NewExperimentSwitch(
+				"pricing",
+				func(p MasterPlan) string {
+					return p.(*experimentTest_Plan).userID
+				},
+				[]Variant{
+					{Name: "control", Weight: 1, NewPlan: newPlan},
+				},
+				WithVariantAssignmentHandler(
+					func(ctx context.Context, va VariantAssignment) {
+						assignments = append(assignments, va)
+					},
+				),
+			)
+		},
+	)
+	e.AnalyzePlan(&experimentTest_Plan{})
+	e.AnalyzePlan(&experimentTest_VariantPlan{})
+
+	p := &experimentTest_Plan{
+		engine: e,
+		userID: "user-1",
+	}
+
+	assert.Nil(t, e.ExecuteMasterPlan(context.Background(), p))
+	assert.Equal(t, "variant", switchedTo.Value.Outcome)
+	assert.Equal(t, []VariantAssignment{{Experiment: "pricing", Variant: "control", Key: "user-1"}}, assignments)
+}
+
+func TestExperimentSwitch_NotConfigured(t *testing.T) {
+	_, err := ExperimentSwitch{}.Switch(context.Background(), nil)
+	assert.Equal(t, ErrExperimentNotConfigured, err)
+}
+
+func TestExperimentSwitch_Assign(t *testing.T) {
+	newPlan := func(p MasterPlan) MasterPlan {
+		return nil
+	}
+
+	s := NewExperimentSwitch(
+		"pricing", nil, []Variant{
+			{Name: "control", Weight: 3, NewPlan: newPlan},
+			{Name: "treatment", Weight: 1, NewPlan: newPlan},
+		},
+	)
+
+	counts := make(map[string]int)
+	for i := 0; i < 10000; i++ {
+		key := fmt.Sprintf("user-%v", i)
+
+		v := s.assign(key)
+		counts[v.Name]++
+
+		// Assignments are sticky
+		assert.Equal(t, v.Name, s.assign(key).Name)
+	}
+
+	assert.InDelta(t, 7500, counts["control"], 300)
+	assert.InDelta(t, 2500, counts["treatment"], 300)
+}
+
+func TestNewExperimentSwitch_Invalid(t *testing.T) {
+	assert.PanicsWithError(
+		t, ErrInvalidExperiment.Err("pricing", "no variant").Error(), func() {
+			NewExperimentSwitch("pricing", nil, nil)
+		},
+	)
+
+	assert.Panics(
+		t, func() {
+			NewExperimentSwitch("pricing", nil, []Variant{{Name: "control"}})
+		},
+	)
+}
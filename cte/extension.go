@@ -0,0 +1,130 @@
+package cte
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// Interceptor wraps the Compute method of a computer. It must call next to carry on with the
+// computation, unless it wants to short-circuit it. For SwitchComputer, the outcome returned
+// by next is opaque and must be returned as is.
+type Interceptor func(ctx context.Context, p MasterPlan, next func(ctx context.Context) (interface{}, error)) (interface{}, error)
+
+// MetadataExtension handles a custom key in CTEMetadata. It is invoked at analysis time, once
+// per computer declaring the key, with the ID of the component and the type declared under
+// the key. It may return an Interceptor to attach behavior to the computer at execution time
+// or nil if it only needs to inspect the declaration, e.g. for validation.
+type MetadataExtension func(componentID string, declaredType reflect.Type) Interceptor
+
+var extensions = struct {
+	sync.RWMutex
+	handlers map[metaType]MetadataExtension
+}{
+	handlers: make(map[metaType]MetadataExtension),
+}
+
+// RegisterMetadataExtension registers the given handler for a custom key in CTEMetadata.
+// Extensions must be registered before analyzing any plan declaring the key, typically in
+// an init function. Registering a built-in key or the same key twice panics.
+func RegisterMetadataExtension(key string, ext MetadataExtension) {
+	extensions.Lock()
+	defer extensions.Unlock()
+
+	mt := metaType(key)
+	if _, ok := extensions.handlers[mt]; ok || mt == metaTypeComputerKey || isBuiltInMetaType(mt) {
+		panic(ErrMetaKeyAlreadyRegistered.Err(key))
+	}
+
+	extensions.handlers[mt] = ext
+}
+
+// unregisterMetadataExtension removes the handler registered for the given key, if any.
+func unregisterMetadataExtension(key string) {
+	extensions.Lock()
+	defer extensions.Unlock()
+
+	delete(extensions.handlers, metaType(key))
+}
+
+func findMetadataExtension(mt metaType) (MetadataExtension, bool) {
+	extensions.RLock()
+	defer extensions.RUnlock()
+
+	ext, ok := extensions.handlers[mt]
+	return ext, ok
+}
+
+func listMetadataExtensions() []metaType {
+	extensions.RLock()
+	defer extensions.RUnlock()
+
+	result := make([]metaType, 0, len(extensions.handlers))
+	for mt := range extensions.handlers {
+		result = append(result, mt)
+	}
+
+	return result
+}
+
+// extractInterceptors returns the interceptors attached by extensions to the component with
+// the given metadata, sorted by key so that they are applied in a deterministic order.
+func extractInterceptors(componentID string, metadata parsedMetadata) []Interceptor {
+	keys := make([]string, 0, len(metadata))
+	for mt := range metadata {
+		keys = append(keys, string(mt))
+	}
+
+	sort.Strings(keys)
+
+	var result []Interceptor
+	for _, key := range keys {
+		ext, ok := findMetadataExtension(metaType(key))
+		if !ok {
+			continue
+		}
+
+		if interceptor := ext(componentID, metadata[metaType(key)]); interceptor != nil {
+			result = append(result, interceptor)
+		}
+	}
+
+	return result
+}
+
+// intercept wraps the given computeFn so that the first interceptor is the outermost one.
+func intercept(fn computeFn, interceptors []Interceptor) computeFn {
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		interceptor := interceptors[i]
+		next := fn
+
+		fn = func(ctx context.Context, p MasterPlan, data LoadingData) (interface{}, error) {
+			return interceptor(
+				ctx, p, func(ctx context.Context) (interface{}, error) {
+					return next(ctx, p, data)
+				},
+			)
+		}
+	}
+
+	return fn
+}
+
+// interceptLoad wraps the given loadFn so that the first interceptor is the outermost one.
+func interceptLoad(fn loadFn, interceptors []Interceptor) loadFn {
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		interceptor := interceptors[i]
+		next := fn
+
+		fn = func(ctx context.Context, p MasterPlan) (interface{}, error) {
+			return interceptor(
+				ctx, p, func(ctx context.Context) (interface{}, error) {
+					return next(ctx, p)
+				},
+			)
+		}
+	}
+
+	return fn
+}
@@ -0,0 +1,140 @@
+package cte
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type extensionTest_Team struct{}
+
+type extensionTest_Computer struct{}
+
+func (extensionTest_Computer) Compute(ctx context.Context, p MasterPlan) (interface{}, error) {
+	return "computed", nil
+}
+
+type extensionTest_Component Result
+
+func (extensionTest_Component) CTEMetadata() interface{} {
+	return struct {
+		computer       extensionTest_Computer
+		extensionOwner extensionTest_Team
+	}{}
+}
+
+type extensionTest_Plan struct {
+	Component extensionTest_Component
+}
+
+func (*extensionTest_Plan) IsSequentialCTEPlan() bool {
+	return true
+}
+
+func (*extensionTest_Plan) Execute(ctx context.Context) error {
+	return nil
+}
+
+func TestRegisterMetadataExtension(t *testing.T) {
+	var declarations []string
+
+	RegisterMetadataExtension(
+		"extensionOwner", func(componentID string, declaredType reflect.Type) Interceptor {
+			declarations = append(declarations, extractShortName(componentID)+"="+declaredType.Name())
+
+			return func(ctx context.Context, p MasterPlan, next func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+				outcome, err := next(ctx)
+				return fmt.Sprintf("%v by %v", outcome, declaredType.Name()), err
+			}
+		},
+	)
+
+	t.Cleanup(
+		func() {
+			unregisterMetadataExtension("extensionOwner")
+		},
+	)
+
+	assert.Panics(
+		t, func() {
+			RegisterMetadataExtension(
+				"extensionOwner", func(componentID string, declaredType reflect.Type) Interceptor {
+					return nil
+				},
+			)
+		},
+	)
+
+	assert.Panics(
+		t, func() {
+			RegisterMetadataExtension(
+				string(metaTypeCache), func(componentID string, declaredType reflect.Type) Interceptor {
+					return nil
+				},
+			)
+		},
+	)
+
+	e := NewEngine()
+	e.AnalyzePlan(&extensionTest_Plan{})
+
+	assert.Equal(t, []string{"extensionTest_Component=extensionTest_Team"}, declarations)
+
+	p := &extensionTest_Plan{}
+	assert.Nil(t, e.ExecuteMasterPlan(context.Background(), p))
+
+	outcome, err := p.Component.Task.Outcome()
+	assert.Equal(t, "computed by extensionTest_Team", outcome)
+	assert.Nil(t, err)
+}
+
+func TestIntercept(t *testing.T) {
+	var calls []string
+
+	makeInterceptor := func(name string) Interceptor {
+		return func(ctx context.Context, p MasterPlan, next func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+			calls = append(calls, name)
+			return next(ctx)
+		}
+	}
+
+	fn := intercept(
+		func(ctx context.Context, p MasterPlan, data LoadingData) (interface{}, error) {
+			calls = append(calls, "compute")
+			return data.Data, nil
+		},
+		[]Interceptor{makeInterceptor("outer"), makeInterceptor("inner")},
+	)
+
+	outcome, err := fn(context.Background(), nil, LoadingData{Data: 1})
+	assert.Equal(t, 1, outcome)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"outer", "inner", "compute"}, calls)
+}
+
+func TestInterceptLoad(t *testing.T) {
+	var calls []string
+
+	makeInterceptor := func(name string) Interceptor {
+		return func(ctx context.Context, p MasterPlan, next func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+			calls = append(calls, name)
+			return next(ctx)
+		}
+	}
+
+	fn := interceptLoad(
+		func(ctx context.Context, p MasterPlan) (interface{}, error) {
+			calls = append(calls, "load")
+			return 1, nil
+		},
+		[]Interceptor{makeInterceptor("outer"), makeInterceptor("inner")},
+	)
+
+	data, err := fn(context.Background(), nil)
+	assert.Equal(t, 1, data)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"outer", "inner", "load"}, calls)
+}
@@ -0,0 +1,206 @@
+package cte
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime/debug"
+	"sort"
+	"sync"
+)
+
+// ComponentState describes how far a component has progressed in an execution.
+type ComponentState int
+
+const (
+	ComponentPending ComponentState = iota
+	ComponentRunning
+	ComponentSucceeded
+	ComponentFailed
+	ComponentSkipped
+)
+
+func (s ComponentState) String() string {
+	switch s {
+	case ComponentRunning:
+		return "Running"
+	case ComponentSucceeded:
+		return "Succeeded"
+	case ComponentFailed:
+		return "Failed"
+	case ComponentSkipped:
+		return "Skipped"
+	default:
+		return "Pending"
+	}
+}
+
+// ComponentStatus is a snapshot of the state of a component in an execution started by
+// Engine.ExecuteMasterPlanAsync.
+type ComponentStatus struct {
+	ID    string
+	State ComponentState
+	// Err is the error the component failed with, nil unless State is ComponentFailed
+	// or ComponentSkipped.
+	Err error
+}
+
+// ExecutionHandle gives access to an execution started by Engine.ExecuteMasterPlanAsync.
+type ExecutionHandle struct {
+	cancel  context.CancelFunc
+	done    chan struct{}
+	err     error
+	tracker *componentTracker
+}
+
+// Wait blocks until the execution completes and returns the same error as
+// Engine.ExecuteMasterPlan would.
+func (h *ExecutionHandle) Wait() error {
+	<-h.done
+	return h.err
+}
+
+// Done returns a channel that is closed once the execution completes.
+func (h *ExecutionHandle) Done() <-chan struct{} {
+	return h.done
+}
+
+// Cancel cancels the context of the execution. Components that already completed keep their
+// outcome while the remaining ones will fail with the context error. Call Wait to find out
+// when the execution actually stops.
+func (h *ExecutionHandle) Cancel() {
+	h.cancel()
+}
+
+// Status returns a snapshot of the state of every component reachable from the executing
+// plan, sorted by ID. Components of plans returned by switch components are included once
+// they start.
+func (h *ExecutionHandle) Status() []ComponentStatus {
+	return h.tracker.snapshot()
+}
+
+// ExecuteMasterPlanAsync starts executing the given master plan in a separate goroutine and
+// returns a handle to wait for, cancel or inspect the execution. The plan must not be
+// accessed until the execution completes. A panic during the execution is recovered and
+// returned by Wait as an error.
+func (e Engine) ExecuteMasterPlanAsync(ctx context.Context, p MasterPlan) *ExecutionHandle {
+	if override, ok := extractEngine(ctx); ok && override.configs != e.configs {
+		return override.ExecuteMasterPlanAsync(ctx, p)
+	}
+
+	planValue := reflect.ValueOf(p).Elem()
+	planName := extractFullNameFromType(planValue.Type())
+
+	// Fail fast on the goroutine of the caller for plans that were not analyzed
+	e.findAnalyzedPlan(planName, planValue)
+
+	tracker := newComponentTracker(e.listComponentIDs(planName))
+
+	ctx, cancel := context.WithCancel(withComponentObserver(ctx, tracker))
+
+	h := &ExecutionHandle{
+		cancel:  cancel,
+		done:    make(chan struct{}),
+		tracker: tracker,
+	}
+
+	go func() {
+		defer close(h.done)
+		defer cancel()
+		defer func() {
+			if r := recover(); r != nil {
+				h.err = fmt.Errorf("panic executing master plan: %v \n %s", r, debug.Stack())
+			}
+		}()
+
+		h.err = e.ExecuteMasterPlan(ctx, p)
+	}()
+
+	return h
+}
+
+// listComponentIDs returns the IDs of all components in the given plan and its nested plans.
+func (e Engine) listComponentIDs(planName string) []string {
+	var result []string
+	for _, component := range e.plans[planName].components {
+		if _, ok := e.plans[component.id]; ok {
+			result = append(result, e.listComponentIDs(component.id)...)
+			continue
+		}
+
+		result = append(result, component.id)
+	}
+
+	return result
+}
+
+type componentTracker struct {
+	mu       sync.RWMutex
+	statuses map[string]ComponentStatus
+}
+
+func newComponentTracker(componentIDs []string) *componentTracker {
+	statuses := make(map[string]ComponentStatus, len(componentIDs))
+	for _, componentID := range componentIDs {
+		statuses[componentID] = ComponentStatus{
+			ID:    componentID,
+			State: ComponentPending,
+		}
+	}
+
+	return &componentTracker{
+		statuses: statuses,
+	}
+}
+
+func (t *componentTracker) componentStarted(ctx context.Context, componentID string) {
+	t.set(
+		ComponentStatus{
+			ID:    componentID,
+			State: ComponentRunning,
+		},
+	)
+}
+
+func (t *componentTracker) componentCompleted(ctx context.Context, componentID string, value interface{}, err error) {
+	state := ComponentSucceeded
+	if errors.Is(err, ErrComponentSkipped) {
+		state = ComponentSkipped
+	} else if err != nil {
+		state = ComponentFailed
+	}
+
+	t.set(
+		ComponentStatus{
+			ID:    componentID,
+			State: state,
+			Err:   err,
+		},
+	)
+}
+
+func (t *componentTracker) set(status ComponentStatus) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.statuses[status.ID] = status
+}
+
+func (t *componentTracker) snapshot() []ComponentStatus {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	result := make([]ComponentStatus, 0, len(t.statuses))
+	for _, status := range t.statuses {
+		result = append(result, status)
+	}
+
+	sort.Slice(
+		result, func(i, j int) bool {
+			return result[i].ID < result[j].ID
+		},
+	)
+
+	return result
+}
@@ -0,0 +1,193 @@
+package cte
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type handleTest_FirstComputer struct{}
+
+func (handleTest_FirstComputer) Compute(ctx context.Context, p MasterPlan) (interface{}, error) {
+	return 1, nil
+}
+
+type handleTest_First SyncResult
+
+func (handleTest_First) CTEMetadata() interface{} {
+	return struct {
+		computer handleTest_FirstComputer
+	}{}
+}
+
+type handleTest_SecondComputer struct{}
+
+func (handleTest_SecondComputer) Compute(ctx context.Context, p MasterPlan) (interface{}, error) {
+	casted := p.(*handleTest_Plan)
+	close(casted.started)
+
+	select {
+	case <-casted.release:
+		return 2, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+type handleTest_Second SyncResult
+
+func (handleTest_Second) CTEMetadata() interface{} {
+	return struct {
+		computer handleTest_SecondComputer
+	}{}
+}
+
+type handleTest_Plan struct {
+	engine  Engine
+	started chan struct{}
+	release chan struct{}
+	First   handleTest_First
+	Second  handleTest_Second
+}
+
+func (*handleTest_Plan) IsSequentialCTEPlan() bool {
+	return true
+}
+
+func (p *handleTest_Plan) Execute(ctx context.Context) error {
+	return p.engine.ExecuteMasterPlan(ctx, p)
+}
+
+type handleTest_UnanalyzedPlan struct {
+	engine Engine
+	First  handleTest_First
+}
+
+func (*handleTest_UnanalyzedPlan) IsSequentialCTEPlan() bool {
+	return true
+}
+
+func (p *handleTest_UnanalyzedPlan) Execute(ctx context.Context) error {
+	return p.engine.ExecuteMasterPlan(ctx, p)
+}
+
+func TestEngine_ExecuteMasterPlanAsync(t *testing.T) {
+	firstID := extractFullNameFromValue(handleTest_First{})
+	secondID := extractFullNameFromValue(handleTest_Second{})
+
+	scenarios := []struct {
+		desc           string
+		finish         func(h *ExecutionHandle, p *handleTest_Plan)
+		expectedErr    error
+		expectedSecond ComponentState
+	}{
+		{
+			desc: "completed",
+			finish: func(h *ExecutionHandle, p *handleTest_Plan) {
+				close(p.release)
+			},
+			expectedSecond: ComponentSucceeded,
+		},
+		{
+			desc: "cancelled",
+			finish: func(h *ExecutionHandle, p *handleTest_Plan) {
+				h.Cancel()
+			},
+			expectedErr:    context.Canceled,
+			expectedSecond: ComponentFailed,
+		},
+	}
+
+	for _, scenario := range scenarios {
+		sc := scenario
+		t.Run(
+			sc.desc, func(t *testing.T) {
+				e := NewEngine()
+				e.AnalyzePlan(&handleTest_Plan{})
+
+				p := &handleTest_Plan{
+					engine:  e,
+					started: make(chan struct{}),
+					release: make(chan struct{}),
+				}
+
+				h := e.ExecuteMasterPlanAsync(context.Background(), p)
+
+				<-p.started
+
+				assert.Equal(
+					t, []ComponentStatus{
+						{ID: firstID, State: ComponentSucceeded},
+						{ID: secondID, State: ComponentRunning},
+					}, h.Status(),
+				)
+
+				select {
+				case <-h.Done():
+					assert.Fail(t, "execution completed before the second component")
+				default:
+				}
+
+				sc.finish(h, p)
+
+				err := h.Wait()
+				assert.True(t, errors.Is(err, sc.expectedErr), err)
+
+				<-h.Done()
+
+				statuses := h.Status()
+				assert.Equal(t, ComponentSucceeded, statuses[0].State)
+				assert.Equal(t, sc.expectedSecond, statuses[1].State)
+			},
+		)
+	}
+}
+
+type handleTest_PanickingPreHook struct{}
+
+func (handleTest_PanickingPreHook) CTEMetadata() interface{} {
+	return struct{}{}
+}
+
+func (handleTest_PanickingPreHook) PreExecute(p Plan) error {
+	panic("hook panicked")
+}
+
+type handleTest_PanickingPlan struct {
+	engine  Engine
+	PreHook handleTest_PanickingPreHook
+	First   handleTest_First
+}
+
+func (*handleTest_PanickingPlan) IsSequentialCTEPlan() bool {
+	return true
+}
+
+func (p *handleTest_PanickingPlan) Execute(ctx context.Context) error {
+	return p.engine.ExecuteMasterPlan(ctx, p)
+}
+
+func TestEngine_ExecuteMasterPlanAsync_Panic(t *testing.T) {
+	e := NewEngine()
+	e.AnalyzePlan(&handleTest_PanickingPlan{})
+
+	h := e.ExecuteMasterPlanAsync(context.Background(), &handleTest_PanickingPlan{engine: e})
+
+	err := h.Wait()
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "hook panicked")
+
+	<-h.Done()
+}
+
+func TestEngine_ExecuteMasterPlanAsync_NotAnalyzed(t *testing.T) {
+	e := NewEngine()
+
+	assert.Panics(
+		t, func() {
+			e.ExecuteMasterPlanAsync(context.Background(), &handleTest_UnanalyzedPlan{engine: e})
+		},
+	)
+}
@@ -0,0 +1,53 @@
+package cte
+
+import (
+	"reflect"
+	"sort"
+)
+
+// ComponentDescription describes how Engine executes a registered component.
+type ComponentDescription struct {
+	ID                string
+	Optional          bool
+	Cached            bool
+	Loading           bool
+	Switch            bool
+	LoaderErrorPolicy LoaderErrorPolicy
+	// Inout is the interface declared under the `inout` key in CTEMetadata, nil if absent.
+	Inout reflect.Type
+}
+
+// Describe returns the description of every component registered in Engine, sorted by ID.
+func (e Engine) Describe() []ComponentDescription {
+	result := make([]ComponentDescription, 0, len(e.computers))
+	for componentID, c := range e.computers {
+		result = append(result, c.describe(componentID))
+	}
+
+	sort.Slice(
+		result, func(i, j int) bool {
+			return result[i].ID < result[j].ID
+		},
+	)
+
+	return result
+}
+
+func (rc registeredComputer) describe(componentID string) ComponentDescription {
+	inout, _ := rc.metadata.getInoutInterface()
+
+	_, isSwitch := rc.instance.(SwitchComputer)
+	if !isSwitch {
+		_, isSwitch = rc.instance.(SwitchComputerWithLoadingData)
+	}
+
+	return ComponentDescription{
+		ID:                componentID,
+		Optional:          rc.metadata.isOptional(),
+		Cached:            rc.cachePolicy != nil,
+		Loading:           rc.computer.loadFn != nil,
+		Switch:            isSwitch,
+		LoaderErrorPolicy: rc.loaderErrorPolicy,
+		Inout:             inout,
+	}
+}
@@ -0,0 +1,30 @@
+package cte
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEngine_Describe(t *testing.T) {
+	e := NewEngine()
+	e.AnalyzePlan(&engineTest_FailPlanOnLoaderErrorPlan{})
+	e.AnalyzePlan(&engineTest_OptionalSlowPlan{})
+
+	expected := []ComponentDescription{
+		{
+			ID:                extractFullNameFromType(reflect.TypeOf(engineTest_FailPlanOnLoaderError{})),
+			Optional:          true,
+			Loading:           true,
+			LoaderErrorPolicy: LoaderErrorFailsPlan,
+		},
+		{
+			ID:                extractFullNameFromType(reflect.TypeOf(engineTest_OptionalSlowValue{})),
+			Optional:          true,
+			LoaderErrorPolicy: LoaderErrorPassThrough,
+		},
+	}
+
+	assert.Equal(t, expected, e.Describe())
+}
@@ -0,0 +1,112 @@
+package cte
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+const latencyWindowSize = 128
+
+// latencyProbeInterval is how often a component that keeps getting skipped is let through
+// anyway so that its latency window can recover once the component gets faster.
+const latencyProbeInterval = 5 * time.Second
+
+// latencyTracker keeps a sliding window of the most recent execution
+// durations of each component.
+type latencyTracker struct {
+	mu      sync.RWMutex
+	windows map[string]*latencyWindow
+}
+
+type latencyWindow struct {
+	durations [latencyWindowSize]time.Duration
+	count     int
+	next      int
+	// lastAt is when the window last recorded a duration or let a probe through.
+	lastAt time.Time
+}
+
+func newLatencyTracker() *latencyTracker {
+	return &latencyTracker{
+		windows: make(map[string]*latencyWindow),
+	}
+}
+
+func (lt *latencyTracker) record(componentID string, startedAt time.Time) {
+	elapsed := time.Since(startedAt)
+
+	lt.mu.Lock()
+	defer lt.mu.Unlock()
+
+	w, ok := lt.windows[componentID]
+	if !ok {
+		w = &latencyWindow{}
+		lt.windows[componentID] = w
+	}
+
+	w.durations[w.next] = elapsed
+	w.next = (w.next + 1) % latencyWindowSize
+	w.lastAt = time.Now()
+
+	if w.count < latencyWindowSize {
+		w.count++
+	}
+}
+
+// percentile returns the latency under which the given fraction (e.g. 0.95) of the
+// recorded executions of a component completed. It returns false if the component
+// has never been executed.
+func (lt *latencyTracker) percentile(componentID string, p float64) (time.Duration, bool) {
+	lt.mu.RLock()
+
+	w, ok := lt.windows[componentID]
+	if !ok || w.count == 0 {
+		lt.mu.RUnlock()
+		return 0, false
+	}
+
+	sorted := make([]time.Duration, w.count)
+	copy(sorted, w.durations[:w.count])
+
+	lt.mu.RUnlock()
+
+	sort.Slice(
+		sorted, func(i, j int) bool {
+			return sorted[i] < sorted[j]
+		},
+	)
+
+	idx := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if idx < 0 {
+		idx = 0
+	}
+
+	if idx >= len(sorted) {
+		idx = len(sorted) - 1
+	}
+
+	return sorted[idx], true
+}
+
+// probe returns true if the given component, which would otherwise be skipped, should be
+// executed anyway because its latency has not been sampled for latencyProbeInterval. Only
+// one caller is let through per interval.
+func (lt *latencyTracker) probe(componentID string, now time.Time) bool {
+	lt.mu.Lock()
+	defer lt.mu.Unlock()
+
+	w, ok := lt.windows[componentID]
+	if !ok {
+		return true
+	}
+
+	if now.Sub(w.lastAt) < latencyProbeInterval {
+		return false
+	}
+
+	w.lastAt = now
+
+	return true
+}
@@ -0,0 +1,70 @@
+package cte
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLatencyTracker_Percentile(t *testing.T) {
+	lt := newLatencyTracker()
+
+	_, ok := lt.percentile("component", 0.95)
+	assert.False(t, ok)
+
+	// Pretend executions took 1ms, 2ms, ..., 100ms
+	w := &latencyWindow{}
+	for i := 1; i <= 100; i++ {
+		w.durations[w.next] = time.Duration(i) * time.Millisecond
+		w.next++
+		w.count++
+	}
+
+	lt.windows["component"] = w
+
+	result, ok := lt.percentile("component", 0.95)
+	assert.True(t, ok)
+	assert.Equal(t, 95*time.Millisecond, result)
+
+	result, ok = lt.percentile("component", 0)
+	assert.True(t, ok)
+	assert.Equal(t, time.Millisecond, result)
+
+	result, ok = lt.percentile("component", 1)
+	assert.True(t, ok)
+	assert.Equal(t, 100*time.Millisecond, result)
+}
+
+func TestLatencyTracker_Record(t *testing.T) {
+	lt := newLatencyTracker()
+
+	for i := 0; i < latencyWindowSize+10; i++ {
+		lt.record("component", time.Now().Add(-time.Millisecond))
+	}
+
+	w := lt.windows["component"]
+	assert.Equal(t, latencyWindowSize, w.count)
+	assert.Equal(t, 10, w.next)
+
+	result, ok := lt.percentile("component", 0.5)
+	assert.True(t, ok)
+	assert.True(t, result >= time.Millisecond)
+}
+
+func TestLatencyTracker_Probe(t *testing.T) {
+	lt := newLatencyTracker()
+
+	assert.True(t, lt.probe("component", time.Now()))
+
+	lt.record("component", time.Now().Add(-time.Millisecond))
+	recordedAt := lt.windows["component"].lastAt
+
+	assert.False(t, lt.probe("component", recordedAt.Add(time.Second)))
+
+	// Only one probe is let through per interval
+	assert.True(t, lt.probe("component", recordedAt.Add(latencyProbeInterval)))
+	assert.False(t, lt.probe("component", recordedAt.Add(latencyProbeInterval+time.Second)))
+
+	assert.True(t, lt.probe("component", recordedAt.Add(2*latencyProbeInterval)))
+}
@@ -0,0 +1,187 @@
+package cte
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// Initializer can be implemented by computers and hooks that must be initialized, e.g. to warm
+// up caches or connection pools, before Engine starts executing plans. See Engine.Start.
+type Initializer interface {
+	Init(ctx context.Context) error
+}
+
+// Closer can be implemented by computers and hooks that hold resources which must be released
+// when Engine shuts down. See Engine.Shutdown.
+type Closer interface {
+	Close(ctx context.Context) error
+}
+
+// executionKey marks a context as belonging to an execution registered in the given lifecycle
+// so that executions of other engines are still registered in their own lifecycle.
+type executionKey struct {
+	l *lifecycle
+}
+
+// lifecycle keeps track of in-flight executions so that Engine can shut down gracefully.
+type lifecycle struct {
+	mu           sync.Mutex
+	inFlight     sync.WaitGroup
+	shuttingDown bool
+}
+
+// enter registers a new execution and returns a function to call once the execution
+// completes. Nested executions on the same Engine, e.g. triggered by a SwitchComputer, are
+// not registered again so that they can still complete while Engine is shutting down.
+func (l *lifecycle) enter(ctx context.Context) (context.Context, func(), error) {
+	if ctx.Value(executionKey{l}) != nil {
+		return ctx, func() {}, nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.shuttingDown {
+		return ctx, nil, ErrEngineShuttingDown
+	}
+
+	l.inFlight.Add(1)
+
+	return context.WithValue(ctx, executionKey{l}, struct{}{}), l.inFlight.Done, nil
+}
+
+// join registers work started on behalf of an in-flight execution that may outlive it, e.g.
+// speculative branches, and returns a function to call once the work completes.
+func (l *lifecycle) join() func() {
+	l.inFlight.Add(1)
+	return l.inFlight.Done
+}
+
+// drain rejects new executions and blocks until all in-flight executions complete
+// or the given context is done, whichever comes first.
+func (l *lifecycle) drain(ctx context.Context) error {
+	l.mu.Lock()
+	l.shuttingDown = true
+	l.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		l.inFlight.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Start initializes every computer and hook that implements Initializer in all plans analyzed
+// so far. Computers are initialized in the order of their component ID, followed by hooks in
+// the order of the name of the plans containing them. The engines executing shadow computers
+// are started afterwards in the order of the name of the plans they shadow. Start stops at the
+// first error.
+func (e Engine) Start(ctx context.Context) error {
+	for _, instance := range e.collectLifecycleInstances() {
+		if i, ok := instance.(Initializer); ok {
+			if err := i.Init(ctx); err != nil {
+				return ErrInitFailed.Err(extractFullNameFromValue(instance), err)
+			}
+		}
+	}
+
+	for _, se := range e.collectShadowEngines() {
+		if err := se.Start(ctx); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Shutdown makes Engine reject new executions with ErrEngineShuttingDown, waits for in-flight
+// executions to complete and then closes every computer and hook that implements Closer in
+// the reverse order of Start. If the given context is done before in-flight executions
+// complete, components are not closed and the context error is returned. Otherwise, all
+// components are closed and the first error encountered is returned.
+func (e Engine) Shutdown(ctx context.Context) error {
+	if err := e.lifecycle.drain(ctx); err != nil {
+		return err
+	}
+
+	var result error
+
+	shadowEngines := e.collectShadowEngines()
+	for i := len(shadowEngines) - 1; i >= 0; i-- {
+		if err := shadowEngines[i].Shutdown(ctx); err != nil && result == nil {
+			result = err
+		}
+	}
+
+	instances := e.collectLifecycleInstances()
+	for i := len(instances) - 1; i >= 0; i-- {
+		if c, ok := instances[i].(Closer); ok {
+			if err := c.Close(ctx); err != nil && result == nil {
+				result = ErrCloseFailed.Err(extractFullNameFromValue(instances[i]), err)
+			}
+		}
+	}
+
+	return result
+}
+
+func (e Engine) collectLifecycleInstances() []interface{} {
+	componentIDs := make([]string, 0, len(e.computers))
+	for componentID := range e.computers {
+		componentIDs = append(componentIDs, componentID)
+	}
+
+	sort.Strings(componentIDs)
+
+	var result []interface{}
+	for _, componentID := range componentIDs {
+		result = append(result, e.computers[componentID].instance)
+	}
+
+	planNames := make([]string, 0, len(e.plans))
+	for planName := range e.plans {
+		planNames = append(planNames, planName)
+	}
+
+	sort.Strings(planNames)
+
+	for _, planName := range planNames {
+		ap := e.plans[planName]
+
+		for _, h := range ap.preHooks {
+			result = append(result, h.hook)
+		}
+
+		for _, h := range ap.postHooks {
+			result = append(result, h.hook)
+		}
+	}
+
+	return result
+}
+
+func (e Engine) collectShadowEngines() []Engine {
+	planNames := make([]string, 0, len(e.configs.shadows))
+	for planName, sc := range e.configs.shadows {
+		if sc.engine != nil {
+			planNames = append(planNames, planName)
+		}
+	}
+
+	sort.Strings(planNames)
+
+	result := make([]Engine, 0, len(planNames))
+	for _, planName := range planNames {
+		result = append(result, *e.configs.shadows[planName].engine)
+	}
+
+	return result
+}
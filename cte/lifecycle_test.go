@@ -0,0 +1,186 @@
+package cte
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type lifecycleTest_Events struct {
+	events []string
+}
+
+type lifecycleTest_Computer struct {
+	events  *lifecycleTest_Events
+	initErr error
+}
+
+func (c *lifecycleTest_Computer) Init(ctx context.Context) error {
+	c.events.events = append(c.events.events, "init computer")
+	return c.initErr
+}
+
+func (c *lifecycleTest_Computer) Close(ctx context.Context) error {
+	c.events.events = append(c.events.events, "close computer")
+	return nil
+}
+
+func (c *lifecycleTest_Computer) Compute(ctx context.Context, p MasterPlan) (interface{}, error) {
+	close(p.(*lifecycleTest_Plan).started)
+	<-p.(*lifecycleTest_Plan).release
+	return nil, nil
+}
+
+type lifecycleTest_Component Result
+
+func (lifecycleTest_Component) CTEMetadata() interface{} {
+	return struct {
+		computer lifecycleTest_Computer
+	}{}
+}
+
+type lifecycleTest_Plan struct {
+	started   chan struct{}
+	release   chan struct{}
+	Component lifecycleTest_Component
+}
+
+func (*lifecycleTest_Plan) IsSequentialCTEPlan() bool {
+	return true
+}
+
+func (*lifecycleTest_Plan) Execute(ctx context.Context) error {
+	return nil
+}
+
+func TestEngine_StartAndShutdown(t *testing.T) {
+	events := &lifecycleTest_Events{}
+
+	e := NewEngine()
+	e.RegisterComputerFactory(
+		lifecycleTest_Component{}, func() interface{} {
+			return &lifecycleTest_Computer{events: events}
+		},
+	)
+	e.AnalyzePlan(&lifecycleTest_Plan{})
+
+	assert.Nil(t, e.Start(context.Background()))
+	assert.Equal(t, []string{"init computer"}, events.events)
+
+	p := &lifecycleTest_Plan{
+		started: make(chan struct{}),
+		release: make(chan struct{}),
+	}
+
+	executed := make(chan error)
+	go func() {
+		executed <- e.ExecuteMasterPlan(context.Background(), p)
+	}()
+
+	<-p.started
+
+	// Shutdown gives up waiting when its context is done
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	assert.Equal(t, context.DeadlineExceeded, e.Shutdown(ctx))
+	assert.Equal(t, []string{"init computer"}, events.events)
+
+	// New executions are rejected while in-flight ones still complete
+	assert.Equal(t, ErrEngineShuttingDown, e.ExecuteMasterPlan(context.Background(), &lifecycleTest_Plan{}))
+
+	close(p.release)
+	assert.Nil(t, <-executed)
+
+	assert.Nil(t, e.Shutdown(context.Background()))
+	assert.Equal(t, []string{"init computer", "close computer"}, events.events)
+}
+
+func TestEngine_Start_Error(t *testing.T) {
+	e := NewEngine()
+	e.RegisterComputerFactory(
+		lifecycleTest_Component{}, func() interface{} {
+			return &lifecycleTest_Computer{
+				events:  &lifecycleTest_Events{},
+				initErr: assert.AnError,
+			}
+		},
+	)
+	e.AnalyzePlan(&lifecycleTest_Plan{})
+
+	err := e.Start(context.Background())
+	assert.True(t, errors.Is(err, assert.AnError))
+}
+
+type lifecycleTest_OuterComputer struct{}
+
+func (lifecycleTest_OuterComputer) Compute(ctx context.Context, p MasterPlan) (interface{}, error) {
+	casted := p.(*lifecycleTest_OuterPlan)
+	return nil, casted.innerEngine.ExecuteMasterPlan(ctx, casted.inner)
+}
+
+type lifecycleTest_OuterComponent Result
+
+func (lifecycleTest_OuterComponent) CTEMetadata() interface{} {
+	return struct {
+		computer lifecycleTest_OuterComputer
+	}{}
+}
+
+type lifecycleTest_OuterPlan struct {
+	innerEngine Engine
+	inner       MasterPlan
+	Component   lifecycleTest_OuterComponent
+}
+
+func (*lifecycleTest_OuterPlan) IsSequentialCTEPlan() bool {
+	return true
+}
+
+func (*lifecycleTest_OuterPlan) Execute(ctx context.Context) error {
+	return nil
+}
+
+func TestEngine_Shutdown_ExecutionFromOtherEngine(t *testing.T) {
+	inner := NewEngine()
+	inner.RegisterComputerFactory(
+		lifecycleTest_Component{}, func() interface{} {
+			return &lifecycleTest_Computer{events: &lifecycleTest_Events{}}
+		},
+	)
+	inner.AnalyzePlan(&lifecycleTest_Plan{})
+
+	outer := NewEngine()
+	outer.AnalyzePlan(&lifecycleTest_OuterPlan{})
+
+	innerPlan := &lifecycleTest_Plan{
+		started: make(chan struct{}),
+		release: make(chan struct{}),
+	}
+
+	p := &lifecycleTest_OuterPlan{
+		innerEngine: inner,
+		inner:       innerPlan,
+	}
+
+	executed := make(chan error)
+	go func() {
+		executed <- outer.ExecuteMasterPlan(context.Background(), p)
+	}()
+
+	<-innerPlan.started
+
+	// The execution started by the outer engine is in flight for the inner one
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	assert.Equal(t, context.DeadlineExceeded, inner.Shutdown(ctx))
+
+	close(innerPlan.release)
+	assert.Nil(t, <-executed)
+
+	assert.Nil(t, inner.Shutdown(context.Background()))
+}
@@ -0,0 +1,149 @@
+package cte
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"github.com/jamestrandung/go-concurrency-117/async"
+	"golang.org/x/sync/singleflight"
+)
+
+type preloadedDataKey struct{}
+
+// preloadedData holds the loading tasks that were hoisted to the start of a master
+// plan execution, keyed by component ID.
+type preloadedData struct {
+	tasks map[string]async.Task
+	// users counts how many times each component appears in the whole plan tree
+	users map[string]int
+}
+
+func withPreloadedData(ctx context.Context, pd preloadedData) context.Context {
+	return context.WithValue(ctx, preloadedDataKey{}, pd)
+}
+
+func extractPreloadedData(ctx context.Context) (preloadedData, bool) {
+	pd, ok := ctx.Value(preloadedDataKey{}).(preloadedData)
+	return pd, ok
+}
+
+// tasksFor returns the preloaded tasks at the same index with the corresponding components.
+func (pd preloadedData) tasksFor(components []parsedComponent) []async.Task {
+	loadingTasks := make([]async.Task, len(components))
+	for idx, component := range components {
+		loadingTasks[idx] = pd.tasks[component.id]
+	}
+
+	return loadingTasks
+}
+
+// ownedTasksFor works like tasksFor but leaves out the tasks that are shared with components
+// outside of the plan whose subtree contains the given users. Only the owned tasks may be
+// cancelled when that plan stops, the others run until the master plan completes.
+func (pd preloadedData) ownedTasksFor(components []parsedComponent, users map[string]int) []async.Task {
+	loadingTasks := make([]async.Task, len(components))
+	for idx, component := range components {
+		if pd.isOwned(component.id, users) {
+			loadingTasks[idx] = pd.tasks[component.id]
+		}
+	}
+
+	return loadingTasks
+}
+
+func (pd preloadedData) isOwned(componentID string, users map[string]int) bool {
+	return users[componentID] == pd.users[componentID]
+}
+
+// cancelOnDeadline cancels the tasks of the components having the given users once the given
+// context exceeds its deadline, typically the time budget of a nested plan. Hoisted loaders
+// start before the plan they belong to and hence, would otherwise outlive its budget. Tasks
+// shared with components outside of this plan are left alone. The returned function stops
+// watching the context and must be called once the plan completes.
+func (pd preloadedData) cancelOnDeadline(ctx context.Context, users map[string]int) func() {
+	done := make(chan struct{})
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			if ctx.Err() != context.DeadlineExceeded {
+				return
+			}
+
+			for componentID := range users {
+				if t, ok := pd.tasks[componentID]; ok && pd.isOwned(componentID, users) {
+					t.Cancel()
+				}
+			}
+		case <-done:
+		}
+	}()
+
+	return func() {
+		close(done)
+	}
+}
+
+// loadGroup shares in-flight calls carrying the same key, see singleflight.Group.
+type loadGroup interface {
+	DoChan(key string, fn func() (interface{}, error)) <-chan singleflight.Result
+}
+
+// deduplicateLoads wraps the given loadFn so that concurrent calls carrying the same key
+// share a single in-flight call. The shared call runs on a context detached from the
+// cancellation of the caller that started it while every caller stops waiting as soon
+// as its own context is done.
+func deduplicateLoads(group loadGroup, componentID string, c DeduplicatedLoadingComputer, load loadFn) loadFn {
+	return func(ctx context.Context, p MasterPlan) (interface{}, error) {
+		key := c.LoadKey(p)
+		if key == "" {
+			return load(ctx, p)
+		}
+
+		sharedCtx := detachedContext{parent: ctx}
+
+		ch := group.DoChan(
+			componentID+"/"+key, func() (data interface{}, err error) {
+				// singleflight re-panics in a separate goroutine which
+				// would crash the application
+				defer func() {
+					if r := recover(); r != nil {
+						err = fmt.Errorf("panic executing deduplicated load: %v \n %s", r, debug.Stack())
+					}
+				}()
+
+				return load(sharedCtx, p)
+			},
+		)
+
+		select {
+		case r := <-ch:
+			return r.Val, r.Err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// detachedContext carries the values of its parent but is never cancelled and has no deadline.
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (detachedContext) Err() error {
+	return nil
+}
+
+func (c detachedContext) Value(key interface{}) interface{} {
+	return c.parent.Value(key)
+}
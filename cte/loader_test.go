@@ -0,0 +1,199 @@
+package cte
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/sync/singleflight"
+)
+
+type deduplicateLoads_Computer struct {
+	key string
+}
+
+func (c deduplicateLoads_Computer) Load(ctx context.Context, p MasterPlan) (interface{}, error) {
+	return nil, nil
+}
+
+func (c deduplicateLoads_Computer) LoadKey(p MasterPlan) string {
+	return c.key
+}
+
+type deduplicateLoads_ValueKey struct{}
+
+// deduplicateLoads_Group signals every time a caller joined or started a call
+type deduplicateLoads_Group struct {
+	singleflight.Group
+	joined chan struct{}
+}
+
+func (g *deduplicateLoads_Group) DoChan(key string, fn func() (interface{}, error)) <-chan singleflight.Result {
+	ch := g.Group.DoChan(key, fn)
+	g.joined <- struct{}{}
+
+	return ch
+}
+
+func TestDeduplicateLoads(t *testing.T) {
+	scenarios := []struct {
+		desc string
+		test func(t *testing.T)
+	}{
+		{
+			desc: "concurrent loads share one call",
+			test: func(t *testing.T) {
+				group := &deduplicateLoads_Group{
+					joined: make(chan struct{}, 5),
+				}
+
+				var count int32
+				release := make(chan struct{})
+
+				load := deduplicateLoads(
+					group, "component", deduplicateLoads_Computer{key: "key"},
+					func(ctx context.Context, p MasterPlan) (interface{}, error) {
+						<-release
+						return atomic.AddInt32(&count, 1), nil
+					},
+				)
+
+				var wg sync.WaitGroup
+				results := make([]interface{}, 5)
+				for i := 0; i < 5; i++ {
+					idx := i
+
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+
+						results[idx], _ = load(context.Background(), nil)
+					}()
+				}
+
+				// Wait for all goroutines to join the in-flight call
+				for range results {
+					<-group.joined
+				}
+
+				close(release)
+				wg.Wait()
+
+				assert.Equal(t, int32(1), count)
+				for _, r := range results {
+					assert.Equal(t, int32(1), r)
+				}
+			},
+		},
+		{
+			desc: "empty key skips deduplication",
+			test: func(t *testing.T) {
+				var count int32
+
+				load := deduplicateLoads(
+					&singleflight.Group{}, "component", deduplicateLoads_Computer{},
+					func(ctx context.Context, p MasterPlan) (interface{}, error) {
+						return atomic.AddInt32(&count, 1), nil
+					},
+				)
+
+				first, _ := load(context.Background(), nil)
+				second, _ := load(context.Background(), nil)
+
+				assert.Equal(t, int32(1), first)
+				assert.Equal(t, int32(2), second)
+			},
+		},
+		{
+			desc: "panic is converted into error",
+			test: func(t *testing.T) {
+				load := deduplicateLoads(
+					&singleflight.Group{}, "component", deduplicateLoads_Computer{key: "key"},
+					func(ctx context.Context, p MasterPlan) (interface{}, error) {
+						panic("something went wrong")
+					},
+				)
+
+				data, err := load(context.Background(), nil)
+				assert.Nil(t, data)
+				assert.Contains(t, err.Error(), "something went wrong")
+			},
+		},
+		{
+			desc: "waiting caller respects its own context",
+			test: func(t *testing.T) {
+				release := make(chan struct{})
+				defer close(release)
+
+				load := deduplicateLoads(
+					&singleflight.Group{}, "component", deduplicateLoads_Computer{key: "key"},
+					func(ctx context.Context, p MasterPlan) (interface{}, error) {
+						<-release
+						return 1, nil
+					},
+				)
+
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+
+				data, err := load(ctx, nil)
+				assert.Nil(t, data)
+				assert.Equal(t, context.Canceled, err)
+			},
+		},
+		{
+			desc: "cancelled caller does not fail the shared call",
+			test: func(t *testing.T) {
+				group := &deduplicateLoads_Group{
+					joined: make(chan struct{}, 2),
+				}
+
+				release := make(chan struct{})
+
+				load := deduplicateLoads(
+					group, "component", deduplicateLoads_Computer{key: "key"},
+					func(ctx context.Context, p MasterPlan) (interface{}, error) {
+						<-release
+
+						if err := ctx.Err(); err != nil {
+							return nil, err
+						}
+
+						return ctx.Value(deduplicateLoads_ValueKey{}), nil
+					},
+				)
+
+				ctx, cancel := context.WithCancel(context.WithValue(context.Background(), deduplicateLoads_ValueKey{}, "value"))
+
+				cancelledErr := make(chan error, 1)
+				go func() {
+					_, err := load(ctx, nil)
+					cancelledErr <- err
+				}()
+
+				<-group.joined
+				cancel()
+				assert.Equal(t, context.Canceled, <-cancelledErr)
+
+				// The shared call keeps running on behalf of the remaining callers
+				ch := make(chan interface{}, 1)
+				go func() {
+					data, _ := load(context.Background(), nil)
+					ch <- data
+				}()
+
+				<-group.joined
+				close(release)
+				assert.Equal(t, "value", <-ch)
+			},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		s := scenario
+
+		t.Run(s.desc, s.test)
+	}
+}
@@ -2,6 +2,7 @@ package cte
 
 import (
     "reflect"
+    "sort"
 )
 
 type metaType string
@@ -10,8 +11,98 @@ const (
     metaTypeComputerKey metaType = "key"
     metaTypeComputer    metaType = "computer"
     metaTypeInout       metaType = "inout"
+    metaTypeOptional    metaType = "optional"
+    metaTypeCache       metaType = "cache"
+    metaTypeOnLoaderErr metaType = "onLoaderError"
+    metaTypeSwitchTgts  metaType = "switchTargets"
 )
 
+// builtInMetaTypes are the keys that clients can declare in CTEMetadata on top of the ones
+// registered via RegisterMetadataExtension. The computer key is populated by the engine
+// itself and hence, cannot be declared.
+var builtInMetaTypes = map[metaType]struct{}{
+    metaTypeComputer:    {},
+    metaTypeInout:       {},
+    metaTypeOptional:    {},
+    metaTypeCache:       {},
+    metaTypeOnLoaderErr: {},
+    metaTypeSwitchTgts:  {},
+}
+
+func isBuiltInMetaType(mt metaType) bool {
+    _, ok := builtInMetaTypes[mt]
+    return ok
+}
+
+func isDeclarableMetaType(mt metaType) bool {
+    if isBuiltInMetaType(mt) {
+        return true
+    }
+
+    _, ok := findMetadataExtension(mt)
+    return ok
+}
+
+func listDeclarableMetaTypes() []string {
+    result := make([]string, 0, len(builtInMetaTypes))
+    for mt := range builtInMetaTypes {
+        result = append(result, string(mt))
+    }
+
+    for _, mt := range listMetadataExtensions() {
+        result = append(result, string(mt))
+    }
+
+    sort.Strings(result)
+
+    return result
+}
+
+// Optional can be declared under the `optional` key in CTEMetadata to mark a component as
+// non-critical. An optional component that fails will have its error recorded in its Result
+// but the enclosing plan will carry on as if nothing happened.
+type Optional struct{}
+
+// FailComponentOnLoaderError can be declared under the `onLoaderError` key in CTEMetadata to
+// fail a component with the loader error without calling Compute.
+type FailComponentOnLoaderError struct{}
+
+// FailPlanOnLoaderError can be declared under the `onLoaderError` key in CTEMetadata to fail
+// the whole plan with the loader error without calling Compute, even if the component is
+// optional.
+type FailPlanOnLoaderError struct{}
+
+// PassThroughLoaderError can be declared under the `onLoaderError` key in CTEMetadata to pass
+// the loader error to Compute via LoadingData.Err. This is the default behavior.
+type PassThroughLoaderError struct{}
+
+// LoaderErrorPolicy describes how the engine handles an error returned by the loader
+// of a component.
+type LoaderErrorPolicy int
+
+const (
+    LoaderErrorPassThrough LoaderErrorPolicy = iota
+    LoaderErrorFailsComponent
+    LoaderErrorFailsPlan
+)
+
+var loaderErrorPolicies = map[reflect.Type]LoaderErrorPolicy{
+    reflect.TypeOf(PassThroughLoaderError{}):     LoaderErrorPassThrough,
+    reflect.TypeOf(FailComponentOnLoaderError{}): LoaderErrorFailsComponent,
+    reflect.TypeOf(FailPlanOnLoaderError{}):      LoaderErrorFailsPlan,
+}
+
+func (p LoaderErrorPolicy) String() string {
+    switch p {
+    case LoaderErrorFailsComponent:
+        return "FailComponent"
+    case LoaderErrorFailsPlan:
+        return "FailPlan"
+    default:
+        return "PassThrough"
+    }
+}
+
 //go:generate mockery --name MetadataProvider --case=underscore --inpackage
 type MetadataProvider interface {
     CTEMetadata() interface{}
@@ -29,8 +120,11 @@ var extractMetadata = func(mp MetadataProvider, isComputerKey bool) parsedMetada
 
     for i := 0; i < rt.NumField(); i++ {
         field := rt.Field(i)
+        mt := metaType(field.Name)
 
-        result[metaType(field.Name)] = field.Type
+        validateMetaField(mp, mt, field.Type)
+
+        result[mt] = field.Type
     }
 
     if isComputerKey {
@@ -40,6 +134,43 @@ var extractMetadata = func(mp MetadataProvider, isComputerKey bool) parsedMetada
     return result
 }
 
+func validateMetaField(mp MetadataProvider, mt metaType, fieldType reflect.Type) {
+    if !isDeclarableMetaType(mt) {
+        panic(ErrUnknownMetaKey.Err(reflect.TypeOf(mp), mt, listDeclarableMetaTypes()))
+    }
+
+    switch mt {
+    case metaTypeComputer:
+        computerType := fieldType
+        if computerType.Kind() == reflect.Pointer {
+            computerType = computerType.Elem()
+        }
+
+        if computerType.Kind() == reflect.Interface {
+            panic(ErrComputerMetaNotConcrete.Err(fieldType, reflect.TypeOf(mp)))
+        }
+    case metaTypeInout:
+        if fieldType.Kind() != reflect.Interface {
+            panic(ErrInoutMetaNotInterface.Err(fieldType, reflect.TypeOf(mp)))
+        }
+    case metaTypeSwitchTgts:
+        if fieldType.Kind() != reflect.Struct {
+            panic(ErrInvalidSwitchTargets.Err(fieldType, reflect.TypeOf(mp)))
+        }
+
+        for i := 0; i < fieldType.NumField(); i++ {
+            targetType := fieldType.Field(i).Type
+            if targetType.Kind() == reflect.Pointer {
+                targetType = targetType.Elem()
+            }
+
+            if targetType.Kind() != reflect.Struct {
+                panic(ErrInvalidSwitchTargets.Err(fieldType, reflect.TypeOf(mp)))
+            }
+        }
+    }
+}
+
 type parsedMetadata map[metaType]reflect.Type
 
 func (pm parsedMetadata) getComputerKeyType() (reflect.Type, bool) {
@@ -56,3 +187,33 @@ func (pm parsedMetadata) getInoutInterface() (reflect.Type, bool) {
     result, ok := pm[metaTypeInout]
     return result, ok
 }
+
+func (pm parsedMetadata) getCachePolicyType() (reflect.Type, bool) {
+    result, ok := pm[metaTypeCache]
+    return result, ok
+}
+
+func (pm parsedMetadata) getLoaderErrorPolicyType() (reflect.Type, bool) {
+    result, ok := pm[metaTypeOnLoaderErr]
+    return result, ok
+}
+
+// getSwitchTargets returns the names of the plans declared under the `switchTargets` key.
+func (pm parsedMetadata) getSwitchTargets() []string {
+    targetsType, ok := pm[metaTypeSwitchTgts]
+    if !ok {
+        return nil
+    }
+
+    result := make([]string, 0, targetsType.NumField())
+    for i := 0; i < targetsType.NumField(); i++ {
+        result = append(result, extractFullNameFromType(targetsType.Field(i).Type))
+    }
+
+    return result
+}
+
+func (pm parsedMetadata) isOptional() bool {
+    _, ok := pm[metaTypeOptional]
+    return ok
+}
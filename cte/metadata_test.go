@@ -57,16 +57,16 @@ func TestExtractMetadata(t *testing.T) {
 
                     return reflect.TypeOf(
                         struct {
-                            field1 string
-                            field2 int
+                            computer string
+                            cache    int
                         }{},
                     )
                 }
 
                 metadata := extractMetadata(mpMock, false)
                 assert.Equal(test, 2, len(metadata))
-                assert.Equal(test, reflect.TypeOf("string"), metadata["field1"])
-                assert.Equal(test, reflect.TypeOf(1), metadata["field2"])
+                assert.Equal(test, reflect.TypeOf("string"), metadata[metaTypeComputer])
+                assert.Equal(test, reflect.TypeOf(1), metadata[metaTypeCache])
 
                 mock.AssertExpectationsForObjects(t, mpMock)
             },
@@ -87,18 +87,74 @@ func TestExtractMetadata(t *testing.T) {
 
                     return reflect.TypeOf(
                         struct {
-                            field1 string
-                            field2 int
+                            computer string
+                            cache    int
                         }{},
                     )
                 }
 
                 metadata := extractMetadata(mpMock, true)
                 assert.Equal(test, 3, len(metadata))
-                assert.Equal(test, reflect.TypeOf("string"), metadata["field1"])
-                assert.Equal(test, reflect.TypeOf(1), metadata["field2"])
+                assert.Equal(test, reflect.TypeOf("string"), metadata[metaTypeComputer])
+                assert.Equal(test, reflect.TypeOf(1), metadata[metaTypeCache])
                 assert.Equal(test, reflect.TypeOf(mpMock), metadata[metaTypeComputerKey])
 
+                mock.AssertExpectationsForObjects(t, mpMock)
+            },
+        },
+        {
+            desc: "invalid metadata",
+            test: func(test *testing.T) {
+                mpMock := &MockMetadataProvider{}
+
+                expectedErrs := []struct {
+                    metadata    interface{}
+                    expectedErr error
+                }{
+                    {
+                        metadata: struct {
+                            computr string
+                        }{},
+                        expectedErr: ErrUnknownMetaKey.Err(reflect.TypeOf(mpMock), "computr", listDeclarableMetaTypes()),
+                    },
+                    {
+                        metadata: struct {
+                            key string
+                        }{},
+                        expectedErr: ErrUnknownMetaKey.Err(reflect.TypeOf(mpMock), "key", listDeclarableMetaTypes()),
+                    },
+                    {
+                        metadata: struct {
+                            computer MetadataProvider
+                        }{},
+                        expectedErr: ErrComputerMetaNotConcrete.Err(reflect.TypeOf((*MetadataProvider)(nil)).Elem(), reflect.TypeOf(mpMock)),
+                    },
+                    {
+                        metadata: struct {
+                            inout string
+                        }{},
+                        expectedErr: ErrInoutMetaNotInterface.Err(reflect.TypeOf(""), reflect.TypeOf(mpMock)),
+                    },
+                }
+
+                for _, e := range expectedErrs {
+                    metadata := e.metadata
+
+                    mpMock.On("CTEMetadata").
+                        Return(metadata).
+                        Once()
+
+                    extractNonPointerType = func(t reflect.Type) reflect.Type {
+                        return reflect.TypeOf(metadata)
+                    }
+
+                    assert.PanicsWithError(
+                        test, e.expectedErr.Error(), func() {
+                            extractMetadata(mpMock, true)
+                        },
+                    )
+                }
+
                 mock.AssertExpectationsForObjects(t, mpMock)
             },
         },
@@ -152,3 +208,47 @@ func TestParsedMetadata_GetInoutInterface(t *testing.T) {
     assert.Equal(t, reflect.TypeOf("dummy"), result)
     assert.True(t, ok)
 }
+
+func TestParsedMetadata_IsOptional(t *testing.T) {
+    var pm parsedMetadata = make(map[metaType]reflect.Type)
+
+    assert.False(t, pm.isOptional())
+
+    pm[metaTypeOptional] = reflect.TypeOf(Optional{})
+
+    assert.True(t, pm.isOptional())
+}
+
+func TestParsedMetadata_GetCachePolicyType(t *testing.T) {
+    var pm parsedMetadata = make(map[metaType]reflect.Type)
+
+    result, ok := pm.getCachePolicyType()
+    assert.Equal(t, reflect.Type(nil), result)
+    assert.False(t, ok)
+
+    pm[metaTypeCache] = reflect.TypeOf("dummy")
+
+    result, ok = pm.getCachePolicyType()
+    assert.Equal(t, reflect.TypeOf("dummy"), result)
+    assert.True(t, ok)
+}
+
+func TestParsedMetadata_GetLoaderErrorPolicyType(t *testing.T) {
+    var pm parsedMetadata = make(map[metaType]reflect.Type)
+
+    result, ok := pm.getLoaderErrorPolicyType()
+    assert.Equal(t, reflect.Type(nil), result)
+    assert.False(t, ok)
+
+    pm[metaTypeOnLoaderErr] = reflect.TypeOf(FailPlanOnLoaderError{})
+
+    result, ok = pm.getLoaderErrorPolicyType()
+    assert.Equal(t, reflect.TypeOf(FailPlanOnLoaderError{}), result)
+    assert.True(t, ok)
+}
+
+func TestLoaderErrorPolicy_String(t *testing.T) {
+    assert.Equal(t, "PassThrough", LoaderErrorPassThrough.String())
+    assert.Equal(t, "FailComponent", LoaderErrorFailsComponent.String())
+    assert.Equal(t, "FailPlan", LoaderErrorFailsPlan.String())
+}
@@ -0,0 +1,79 @@
+package cte
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+type earlyOutcomeKey struct{}
+
+type endingEarlyWithValue struct {
+	value interface{}
+}
+
+func (e endingEarlyWithValue) Error() string {
+	return ErrRootPlanExecutionEndingEarly.Error()
+}
+
+func (e endingEarlyWithValue) Unwrap() error {
+	return ErrRootPlanExecutionEndingEarly
+}
+
+// EndEarlyWith returns an error that clients can throw to end the execution of the current
+// master plan and supply its final outcome at the same time. For example, a quote was found
+// in cache and should be returned as is without executing the rest of the plan.
+//
+// Similar to ErrRootPlanExecutionEndingEarly, if the ending plan is nested inside another
+// plan, the outer plan will also end. The engine will swallow this error and deliver the
+// given value to the EarlyOutcome captured via CaptureEarlyOutcome. If the master plan was
+// returned by a SwitchComputer, the value will become the outcome in the Result of the
+// switch component instead of the master plan itself.
+func EndEarlyWith(value interface{}) error {
+	return endingEarlyWithValue{
+		value: value,
+	}
+}
+
+// EarlyOutcome holds the value supplied via EndEarlyWith during a master plan execution.
+type EarlyOutcome struct {
+	mu    sync.RWMutex
+	value interface{}
+	isSet bool
+}
+
+// Value returns the value supplied via EndEarlyWith and whether the master plan actually
+// ended early with a value.
+func (o *EarlyOutcome) Value() (interface{}, bool) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	return o.value, o.isSet
+}
+
+func (o *EarlyOutcome) set(value interface{}) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.value = value
+	o.isSet = true
+}
+
+// CaptureEarlyOutcome returns a context that must be passed to MasterPlan.Execute for clients
+// to retrieve the value supplied via EndEarlyWith once the execution completes.
+func CaptureEarlyOutcome(ctx context.Context) (context.Context, *EarlyOutcome) {
+	eo := &EarlyOutcome{}
+	return context.WithValue(ctx, earlyOutcomeKey{}, eo), eo
+}
+
+func deliverEarlyOutcome(ctx context.Context, err error) {
+	// The error may have been wrapped by an interceptor
+	var ev endingEarlyWithValue
+	if !errors.As(err, &ev) {
+		return
+	}
+
+	if eo, ok := ctx.Value(earlyOutcomeKey{}).(*EarlyOutcome); ok {
+		eo.set(ev.value)
+	}
+}
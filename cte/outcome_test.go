@@ -0,0 +1,78 @@
+package cte
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEndEarlyWith(t *testing.T) {
+	err := EndEarlyWith(1)
+
+	assert.True(t, errors.Is(err, ErrRootPlanExecutionEndingEarly))
+	assert.False(t, errors.Is(err, ErrPlanExecutionEndingEarly))
+	assert.Equal(t, ErrRootPlanExecutionEndingEarly.Error(), err.Error())
+}
+
+func TestDeliverEarlyOutcome(t *testing.T) {
+	scenarios := []struct {
+		desc string
+		test func(t *testing.T)
+	}{
+		{
+			desc: "value is delivered to captured outcome",
+			test: func(t *testing.T) {
+				ctx, eo := CaptureEarlyOutcome(context.Background())
+
+				deliverEarlyOutcome(ctx, EndEarlyWith(1))
+
+				value, ok := eo.Value()
+				assert.Equal(t, 1, value)
+				assert.True(t, ok)
+			},
+		},
+		{
+			desc: "value is delivered from wrapped error",
+			test: func(t *testing.T) {
+				ctx, eo := CaptureEarlyOutcome(context.Background())
+
+				deliverEarlyOutcome(ctx, fmt.Errorf("intercepted: %w", EndEarlyWith(1)))
+
+				value, ok := eo.Value()
+				assert.Equal(t, 1, value)
+				assert.True(t, ok)
+			},
+		},
+		{
+			desc: "other errors are ignored",
+			test: func(t *testing.T) {
+				ctx, eo := CaptureEarlyOutcome(context.Background())
+
+				deliverEarlyOutcome(ctx, ErrRootPlanExecutionEndingEarly)
+
+				value, ok := eo.Value()
+				assert.Nil(t, value)
+				assert.False(t, ok)
+			},
+		},
+		{
+			desc: "no captured outcome",
+			test: func(t *testing.T) {
+				assert.NotPanics(
+					t, func() {
+						deliverEarlyOutcome(context.Background(), EndEarlyWith(1))
+					},
+				)
+			},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		s := scenario
+
+		t.Run(s.desc, s.test)
+	}
+}
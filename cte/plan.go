@@ -2,6 +2,7 @@ package cte
 
 import (
 	"context"
+	"time"
 )
 
 //go:generate mockery --name Plan --case=underscore --inpackage
@@ -15,6 +16,13 @@ type MasterPlan interface {
 	Execute(ctx context.Context) error
 }
 
+// BudgetedPlan can be implemented by any plan, nested or master, that must complete within
+// a fixed amount of time independently of the deadline carried by the incoming context.
+type BudgetedPlan interface {
+	Plan
+	CTEBudget() time.Duration
+}
+
 //go:generate mockery --name Pre --case=underscore --inpackage
 type Pre interface {
 	PreExecute(p Plan) error
@@ -245,6 +245,13 @@ func (ms methodSet) items() []method {
 		methods = append(methods, key)
 	}
 
+	// Keep the order stable so that error messages are deterministic
+	sort.Slice(
+		methods, func(i, j int) bool {
+			return methods[i].String() < methods[j].String()
+		},
+	)
+
 	return methods
 }
 
@@ -0,0 +1,205 @@
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"reflect"
+	"sync"
+
+	"github.com/jamestrandung/go-cte-117/cte"
+	"github.com/jamestrandung/go-cte-117/cte/ctetest"
+)
+
+// Recording captures everything that happened during the execution of a master plan.
+type Recording struct {
+	Plan string `json:"plan"`
+	// Input holds the values of the getters of the master plan before the execution.
+	Input    map[string]Value `json:"input,omitempty"`
+	Loads    []LoadRecord     `json:"loads,omitempty"`
+	Computes []ComputeRecord  `json:"computes,omitempty"`
+	// Result holds the values of the getters of the master plan after the execution.
+	Result map[string]Value `json:"result,omitempty"`
+	Error  string           `json:"error,omitempty"`
+}
+
+// LoadRecord captures the output of the loader of a component.
+type LoadRecord struct {
+	ComponentID string `json:"componentID"`
+	Data        Value  `json:"data"`
+	Error       string `json:"error,omitempty"`
+}
+
+// ComputeRecord captures the computation of a component.
+type ComputeRecord struct {
+	ComponentID string `json:"componentID"`
+	// Inout holds the values of the getters of the inout interface of the component right
+	// before it was computed.
+	Inout map[string]Value `json:"inout,omitempty"`
+	// Outcome is always empty for components using a SwitchComputer.
+	Outcome Value  `json:"outcome"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Save writes the recording to the given file as JSON.
+func (r *Recording) Save(path string) error {
+	content, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, content, 0o644)
+}
+
+// Load reads a recording from the given file.
+func Load(path string) (*Recording, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var result Recording
+	if err := json.Unmarshal(content, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+type sessionKey struct{}
+
+type session struct {
+	mu        sync.Mutex
+	recording *Recording
+}
+
+// RecordingOptions returns the options to configure an Engine with so that executions started
+// via Record are recorded. Other executions are not affected.
+func RecordingOptions() []cte.EngineOption {
+	return []cte.EngineOption{
+		cte.WithInterceptor(interceptCompute),
+		cte.WithLoaderInterceptor(interceptLoad),
+	}
+}
+
+// Record executes the given master plan and captures the outcome of its components. The
+// plan must be executed by an Engine configured with RecordingOptions. The recording is
+// returned along with the error of the execution, if any.
+func Record(ctx context.Context, p cte.MasterPlan) (*Recording, error) {
+	s := &session{
+		recording: &Recording{
+			Plan:  typeName(reflect.TypeOf(p)),
+			Input: encodeValues(ctetest.Getters(p, nil)),
+		},
+	}
+
+	err := p.Execute(context.WithValue(ctx, sessionKey{}, s))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.recording.Result = encodeValues(ctetest.Getters(p, nil))
+	if err != nil {
+		s.recording.Error = err.Error()
+	}
+
+	return s.recording, err
+}
+
+func extractSession(ctx context.Context) (*session, bool) {
+	s, ok := ctx.Value(sessionKey{}).(*session)
+	return s, ok
+}
+
+func interceptCompute(d cte.ComponentDescription) cte.Interceptor {
+	return func(ctx context.Context, p cte.MasterPlan, next func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+		s, ok := extractSession(ctx)
+		if !ok {
+			return next(ctx)
+		}
+
+		inout := encodeValues(ctetest.Getters(p, d.Inout))
+
+		outcome, err := next(ctx)
+
+		record := ComputeRecord{
+			ComponentID: d.ID,
+			Inout:       inout,
+			Error:       errorMessage(err),
+		}
+
+		if !d.Switch {
+			record.Outcome = encodeValue(outcome)
+		}
+
+		// Candidates started speculatively must only be recorded if they are kept
+		cte.WhenKept(
+			ctx, func() {
+				s.mu.Lock()
+				s.recording.Computes = append(s.recording.Computes, record)
+				s.mu.Unlock()
+			},
+		)
+
+		return outcome, err
+	}
+}
+
+func interceptLoad(d cte.ComponentDescription) cte.Interceptor {
+	return func(ctx context.Context, p cte.MasterPlan, next func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+		s, ok := extractSession(ctx)
+		if !ok {
+			return next(ctx)
+		}
+
+		data, err := next(ctx)
+
+		record := LoadRecord{
+			ComponentID: d.ID,
+			Data:        encodeValue(data),
+			Error:       errorMessage(err),
+		}
+
+		cte.WhenKept(
+			ctx, func() {
+				s.mu.Lock()
+				s.recording.Loads = append(s.recording.Loads, record)
+				s.mu.Unlock()
+			},
+		)
+
+		return data, err
+	}
+}
+
+func errorMessage(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	return err.Error()
+}
+
+// sentinelErrs are restored as is when replaying so that the engine handles them the same way.
+var sentinelErrs = []error{
+	cte.ErrPlanExecutionEndingEarly,
+	cte.ErrRootPlanExecutionEndingEarly,
+	cte.ErrComponentSkipped,
+	context.Canceled,
+	context.DeadlineExceeded,
+}
+
+func restoreError(message string) error {
+	if message == "" {
+		return nil
+	}
+
+	for _, err := range sentinelErrs {
+		if err.Error() == message {
+			return err
+		}
+	}
+
+	return errors.New(message)
+}
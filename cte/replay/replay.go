@@ -0,0 +1,157 @@
+package replay
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/jamestrandung/go-cte-117/cte"
+	"github.com/jamestrandung/go-cte-117/cte/ctetest"
+)
+
+type replayConfigs struct {
+	live map[string]struct{}
+	// allLive makes every computer run live, see Simulate
+	allLive        bool
+	harnessOptions []ctetest.Option
+}
+
+type Option func(*replayConfigs)
+
+// WithLive makes the components with the given keys run their real Compute method during
+// replay instead of producing their recorded outcome. Their loaders still produce the
+// recorded data so that no external dependency is called by loaders.
+//
+// Note: Stubbed components do not call the setters that their Compute method would call on
+// the plan. Components relying on setters, e.g. SideEffectComputer, must be chosen via this
+// option for their effects to be replayed.
+func WithLive(keys ...cte.MetadataProvider) Option {
+	return func(configs *replayConfigs) {
+		for _, key := range keys {
+			configs.live[ctetest.ComponentID(key)] = struct{}{}
+		}
+	}
+}
+
+// WithHarnessOptions applies the given options to the Harness replaying the execution, e.g.
+// ctetest.WithPlans to provide the plans returned by SwitchComputer.
+func WithHarnessOptions(options ...ctetest.Option) Option {
+	return func(configs *replayConfigs) {
+		configs.harnessOptions = append(configs.harnessOptions, options...)
+	}
+}
+
+// Replay executes the given master plan, which must be built with the same input as the
+// recorded one, on an isolated Engine. Components produce the outcomes captured in the
+// recording in the same order as they were recorded so that no external dependency is called,
+// except for components using a SwitchComputer and the ones chosen via WithLive which always
+// run live on top of the recorded data of their loaders. Replay fails if the execution asks
+// for more outcomes or data than recorded. The returned Harness exposes how every component
+// was executed.
+func Replay(ctx context.Context, rec *Recording, p cte.MasterPlan, options ...Option) (*ctetest.Harness, error) {
+	configs := &replayConfigs{
+		live: make(map[string]struct{}),
+	}
+
+	for _, o := range options {
+		o(configs)
+	}
+
+	r := newReplayer(rec, configs.live, configs.allLive)
+
+	harnessOptions := append(
+		[]ctetest.Option{
+			ctetest.WithEngineOptions(
+				cte.WithInterceptor(r.interceptCompute),
+				cte.WithLoaderInterceptor(r.interceptLoad),
+			),
+		},
+		configs.harnessOptions...,
+	)
+
+	h := ctetest.New(p, harnessOptions...)
+
+	return h, h.Execute(ctx, p)
+}
+
+type replayer struct {
+	mu       sync.Mutex
+	live     map[string]struct{}
+	allLive  bool
+	loads    map[string][]LoadRecord
+	computes map[string][]ComputeRecord
+}
+
+func newReplayer(rec *Recording, live map[string]struct{}, allLive bool) *replayer {
+	r := &replayer{
+		live:     live,
+		allLive:  allLive,
+		loads:    make(map[string][]LoadRecord),
+		computes: make(map[string][]ComputeRecord),
+	}
+
+	for _, l := range rec.Loads {
+		r.loads[l.ComponentID] = append(r.loads[l.ComponentID], l)
+	}
+
+	for _, c := range rec.Computes {
+		r.computes[c.ComponentID] = append(r.computes[c.ComponentID], c)
+	}
+
+	return r
+}
+
+// isLive returns whether the given component must run its real Compute method. Switches
+// always run live since their outcome is the plan they execute.
+func (r *replayer) isLive(d cte.ComponentDescription) bool {
+	_, ok := r.live[d.ID]
+	return ok || r.allLive || d.Switch
+}
+
+func (r *replayer) interceptCompute(d cte.ComponentDescription) cte.Interceptor {
+	if r.isLive(d) {
+		return nil
+	}
+
+	return func(ctx context.Context, p cte.MasterPlan, next func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+		r.mu.Lock()
+		records := r.computes[d.ID]
+		if len(records) > 0 {
+			r.computes[d.ID] = records[1:]
+		}
+		r.mu.Unlock()
+
+		if len(records) == 0 {
+			return nil, fmt.Errorf("component [%v] has no more recorded outcome", d.ID)
+		}
+
+		outcome, err := records[0].Outcome.Decode()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode recorded outcome of [%v]: %w", d.ID, err)
+		}
+
+		return outcome, restoreError(records[0].Error)
+	}
+}
+
+func (r *replayer) interceptLoad(d cte.ComponentDescription) cte.Interceptor {
+	return func(ctx context.Context, p cte.MasterPlan, next func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+		r.mu.Lock()
+		records := r.loads[d.ID]
+		if len(records) > 0 {
+			r.loads[d.ID] = records[1:]
+		}
+		r.mu.Unlock()
+
+		if len(records) == 0 {
+			return nil, fmt.Errorf("component [%v] has no more recorded data", d.ID)
+		}
+
+		data, err := records[0].Data.Decode()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode recorded data of [%v]: %w", d.ID, err)
+		}
+
+		return data, restoreError(records[0].Error)
+	}
+}
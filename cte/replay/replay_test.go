@@ -0,0 +1,271 @@
+package replay
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/jamestrandung/go-cte-117/cte"
+	"github.com/jamestrandung/go-cte-117/cte/ctetest"
+	"github.com/stretchr/testify/assert"
+)
+
+type route struct {
+	Distance float64
+}
+
+func init() {
+	RegisterType(route{})
+}
+
+// Stands for an external dependency whose response changes over time
+var liveDistance = 4.0
+
+var engine = cte.NewEngine(RecordingOptions()...)
+
+type routeComputer struct{}
+
+func (routeComputer) Load(ctx context.Context, p cte.MasterPlan) (interface{}, error) {
+	return route{Distance: liveDistance}, nil
+}
+
+func (routeComputer) Compute(ctx context.Context, p cte.MasterPlan, data cte.LoadingData) (interface{}, error) {
+	return data.Data, data.Err
+}
+
+type routeInout interface {
+	GetPointA() string
+}
+
+type Route cte.SyncResult
+
+func (Route) CTEMetadata() interface{} {
+	return struct {
+		computer routeComputer
+		inout    routeInout
+	}{}
+}
+
+func (r Route) GetDistance() float64 {
+	return r.Outcome.(route).Distance
+}
+
+type costComputer struct{}
+
+func (costComputer) Compute(ctx context.Context, p cte.MasterPlan) (interface{}, error) {
+	return p.(costInout).GetDistance() * costPerKilometer, nil
+}
+
+// Stands for a pricing rule whose bug is being investigated
+var costPerKilometer = 2.0
+
+type costInout interface {
+	GetDistance() float64
+}
+
+type Cost cte.SyncResult
+
+func (Cost) CTEMetadata() interface{} {
+	return struct {
+		computer costComputer
+		inout    costInout
+	}{}
+}
+
+func (c Cost) GetCost() float64 {
+	return c.Outcome.(float64)
+}
+
+// Stands for a fee added on top of the cost by a component that only calls a setter
+const fee = 1.0
+
+type feeComputer struct{}
+
+func (feeComputer) Compute(ctx context.Context, p cte.MasterPlan) error {
+	casted := p.(feeInout)
+	casted.SetTotal(casted.GetCost() + fee)
+
+	return nil
+}
+
+type feeInout interface {
+	GetCost() float64
+	SetTotal(float64)
+}
+
+type Fee cte.SyncSideEffect
+
+func (Fee) CTEMetadata() interface{} {
+	return struct {
+		computer feeComputer
+		inout    feeInout
+	}{}
+}
+
+type testPlan struct {
+	pointA string
+	total  float64
+	Route
+	Cost
+	Fee
+}
+
+func (p *testPlan) GetTotal() float64 {
+	return p.total
+}
+
+func (p *testPlan) SetTotal(total float64) {
+	p.total = total
+}
+
+func (p *testPlan) GetPointA() string {
+	return p.pointA
+}
+
+func (*testPlan) IsSequentialCTEPlan() bool {
+	return true
+}
+
+func (p *testPlan) Execute(ctx context.Context) error {
+	return engine.ExecuteMasterPlan(ctx, p)
+}
+
+func init() {
+	engine.AnalyzePlan(&testPlan{})
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	defer func(distance, cost float64) {
+		liveDistance, costPerKilometer = distance, cost
+	}(liveDistance, costPerKilometer)
+
+	rec, err := Record(context.Background(), &testPlan{pointA: "Clementi"})
+	assert.Nil(t, err)
+
+	assert.Equal(t, "*github.com/jamestrandung/go-cte-117/cte/replay.testPlan", rec.Plan)
+	assert.Equal(t, `"Clementi"`, string(rec.Input["GetPointA"].Value))
+	assert.Equal(t, `8`, string(rec.Result["GetCost"].Value))
+
+	assert.Equal(t, 1, len(rec.Loads))
+	assert.Equal(t, ctetest.ComponentID(Route{}), rec.Loads[0].ComponentID)
+	assert.Equal(t, `{"Distance":4}`, string(rec.Loads[0].Data.Value))
+
+	assert.Equal(t, `9`, string(rec.Result["GetTotal"].Value))
+	assert.Equal(t, 3, len(rec.Computes))
+	assert.Equal(t, `"Clementi"`, string(rec.Computes[0].Inout["GetPointA"].Value))
+	assert.Equal(t, `4`, string(rec.Computes[1].Inout["GetDistance"].Value))
+
+	path := filepath.Join(t.TempDir(), "recording.json")
+	assert.Nil(t, rec.Save(path))
+
+	loaded, err := Load(path)
+	assert.Nil(t, err)
+	assert.Equal(t, rec.Plan, loaded.Plan)
+	assert.Equal(t, len(rec.Computes), len(loaded.Computes))
+
+	data, err := loaded.Loads[0].Data.Decode()
+	assert.Nil(t, err)
+	assert.Equal(t, route{Distance: 4}, data)
+
+	// The live world has moved on since the recording
+	liveDistance = 100
+
+	// The logic has changed since the recording as well
+	costPerKilometer = 3
+
+	// Components produce their recorded outcomes
+	p := &testPlan{pointA: "Clementi"}
+	h, err := Replay(context.Background(), loaded, p)
+	assert.Nil(t, err)
+	assert.Equal(t, 4.0, p.GetDistance())
+	assert.Equal(t, 8.0, p.GetCost())
+	assert.Equal(
+		t,
+		[]string{ctetest.ComponentID(Route{}), ctetest.ComponentID(Cost{}), ctetest.ComponentID(Fee{})},
+		h.ExecutionOrder(),
+	)
+
+	// Setters are only called by components running live
+	assert.Equal(t, 0.0, p.GetTotal())
+
+	p = &testPlan{pointA: "Clementi"}
+	_, err = Replay(context.Background(), loaded, p, WithLive(Fee{}))
+	assert.Nil(t, err)
+	assert.Equal(t, 8.0, p.GetCost())
+	assert.Equal(t, 9.0, p.GetTotal())
+
+	// Live components pick up changes in logic while loaders keep producing recorded data
+	p = &testPlan{pointA: "Clementi"}
+	_, err = Replay(context.Background(), loaded, p, WithLive(Cost{}, Fee{}))
+	assert.Nil(t, err)
+	assert.Equal(t, 4.0, p.GetDistance())
+	assert.Equal(t, 12.0, p.GetCost())
+	assert.Equal(t, 13.0, p.GetTotal())
+
+	// Replay must not diverge silently from the recording
+	missingLoads := *loaded
+	missingLoads.Loads = nil
+
+	_, err = Replay(context.Background(), &missingLoads, &testPlan{pointA: "Clementi"}, WithLive(Route{}))
+	assert.NotNil(t, err)
+
+	missingComputes := *loaded
+	missingComputes.Computes = nil
+
+	_, err = Replay(context.Background(), &missingComputes, &testPlan{pointA: "Clementi"})
+	assert.NotNil(t, err)
+}
+
+func TestRecordingOptions_WithoutSession(t *testing.T) {
+	p := &testPlan{pointA: "Clementi"}
+	assert.Nil(t, p.Execute(context.Background()))
+	assert.Equal(t, 8.0, p.GetCost())
+	assert.Equal(t, 9.0, p.GetTotal())
+}
+
+type discountedCostComputer struct{}
+
+func (discountedCostComputer) Compute(ctx context.Context, p cte.MasterPlan) (interface{}, error) {
+	return p.(costInout).GetDistance()*costPerKilometer - 1, nil
+}
+
+func TestSimulate(t *testing.T) {
+	rec, err := Record(context.Background(), &testPlan{pointA: "Clementi"})
+	assert.Nil(t, err)
+
+	path := filepath.Join(t.TempDir(), "recording.json")
+	assert.Nil(t, rec.Save(path))
+
+	loaded, err := Load(path)
+	assert.Nil(t, err)
+
+	report := Simulate(context.Background(), loaded, &testPlan{pointA: "Clementi"})
+	assert.Nil(t, report.Error)
+	assert.False(t, report.HasChanges())
+
+	report = Simulate(context.Background(), rec, &testPlan{pointA: "Clementi"}, WithSwap(Cost{}, discountedCostComputer{}))
+	assert.Nil(t, report.Error)
+	assert.True(t, report.HasChanges())
+
+	assert.Equal(t, 4, len(report.Diffs))
+	assert.Equal(t, "GetCost", report.Diffs[0].Getter)
+	assert.True(t, report.Diffs[0].Changed)
+	assert.Equal(t, `8`, string(report.Diffs[0].Recorded.Value))
+	assert.Equal(t, `7`, string(report.Diffs[0].Simulated.Value))
+	assert.False(t, report.Diffs[1].Changed)
+	assert.False(t, report.Diffs[2].Changed)
+
+	// Fee is downstream of the swapped component and hence, gets recomputed
+	assert.Equal(t, "GetTotal", report.Diffs[3].Getter)
+	assert.True(t, report.Diffs[3].Changed)
+	assert.Equal(t, `9`, string(report.Diffs[3].Recorded.Value))
+	assert.Equal(t, `8`, string(report.Diffs[3].Simulated.Value))
+
+	assert.Equal(t, rec.Plan+"\n  GetCost: 8 -> 7\n  GetTotal: 9 -> 8", report.String())
+
+	// A swapped component runs live when replayed too
+	p := &testPlan{pointA: "Clementi"}
+	_, err = Replay(context.Background(), rec, p, WithSwap(Cost{}, discountedCostComputer{}))
+	assert.Nil(t, err)
+	assert.Equal(t, 7.0, p.GetCost())
+}
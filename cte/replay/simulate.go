@@ -0,0 +1,144 @@
+package replay
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jamestrandung/go-cte-117/cte"
+	"github.com/jamestrandung/go-cte-117/cte/ctetest"
+)
+
+// WithSwap makes the component with the given key run the given computer, which can be of any
+// computer type, instead of the one declared in its CTEMetadata. The component runs live as if
+// it was chosen via WithLive.
+func WithSwap(key cte.MetadataProvider, computer interface{}) Option {
+	return func(configs *replayConfigs) {
+		configs.live[ctetest.ComponentID(key)] = struct{}{}
+		configs.harnessOptions = append(
+			configs.harnessOptions,
+			ctetest.WithEngineOptions(cte.WithComputerSubstitute(key, computer)),
+		)
+	}
+}
+
+// GetterDiff compares the value of a getter of the master plan in a recording with its value
+// after a simulation.
+type GetterDiff struct {
+	Getter    string
+	Recorded  Value
+	Simulated Value
+	Changed   bool
+}
+
+// Report describes how the outputs of a recorded execution changed in a simulation.
+type Report struct {
+	Plan  string
+	Diffs []GetterDiff
+	// Error is the error of the simulated execution, if any.
+	Error error
+}
+
+// HasChanges returns whether any getter changed in the simulation.
+func (r Report) HasChanges() bool {
+	for _, d := range r.Diffs {
+		if d.Changed {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r Report) String() string {
+	var sb strings.Builder
+
+	sb.WriteString(r.Plan)
+	if r.Error != nil {
+		sb.WriteString(fmt.Sprintf(" (error: %v)", r.Error))
+	}
+
+	for _, d := range r.Diffs {
+		if d.Changed {
+			sb.WriteString(fmt.Sprintf("\n  %v: %s -> %s", d.Getter, d.Recorded.Value, d.Simulated.Value))
+		}
+	}
+
+	return sb.String()
+}
+
+// Simulate replays the given recording like Replay, typically with some computers swapped via
+// WithSwap, and compares the getters of the master plan after the simulation with their values
+// in the recording. Unlike Replay, every computer runs live so that components downstream of a
+// swapped one are recomputed using its new outcome, only loaders produce recorded data.
+//
+// Note: Computers calling external dependencies in their Compute method will call them during
+// the simulation.
+func Simulate(ctx context.Context, rec *Recording, p cte.MasterPlan, options ...Option) Report {
+	_, err := Replay(ctx, rec, p, append([]Option{withAllLive()}, options...)...)
+
+	return Report{
+		Plan:  rec.Plan,
+		Diffs: diffGetters(rec.Result, encodeValues(ctetest.Getters(p, nil))),
+		Error: err,
+	}
+}
+
+func withAllLive() Option {
+	return func(configs *replayConfigs) {
+		configs.allLive = true
+	}
+}
+
+func diffGetters(recorded map[string]Value, simulated map[string]Value) []GetterDiff {
+	getters := make(map[string]struct{}, len(recorded))
+	for g := range recorded {
+		getters[g] = struct{}{}
+	}
+
+	for g := range simulated {
+		getters[g] = struct{}{}
+	}
+
+	result := make([]GetterDiff, 0, len(getters))
+	for g := range getters {
+		r, s := recorded[g], simulated[g]
+
+		result = append(
+			result, GetterDiff{
+				Getter:    g,
+				Recorded:  r,
+				Simulated: s,
+				Changed:   !r.equal(s),
+			},
+		)
+	}
+
+	sort.Slice(
+		result, func(i, j int) bool {
+			return result[i].Getter < result[j].Getter
+		},
+	)
+
+	return result
+}
+
+func (v Value) equal(other Value) bool {
+	if v.Type != other.Type || v.Unserializable != other.Unserializable {
+		return false
+	}
+
+	return bytes.Equal(compactJSON(v.Value), compactJSON(other.Value))
+}
+
+func compactJSON(content json.RawMessage) []byte {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, content); err != nil {
+		return content
+	}
+
+	return buf.Bytes()
+}
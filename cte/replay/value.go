@@ -0,0 +1,133 @@
+package replay
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Value is a JSON-encoded value along with the name of its type so that it can be decoded
+// back into the same type when replaying.
+type Value struct {
+	Type  string          `json:"type,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+	// Unserializable holds the reason why the value could not be encoded, if any.
+	Unserializable string `json:"unserializable,omitempty"`
+}
+
+var types = struct {
+	sync.RWMutex
+	byName map[string]reflect.Type
+}{
+	byName: make(map[string]reflect.Type),
+}
+
+func init() {
+	for _, prototype := range []interface{}{
+		false, "", 0, int8(0), int16(0), int32(0), int64(0), uint(0), uint8(0), uint16(0), uint32(0), uint64(0),
+		float32(0), float64(0), []byte(nil), []string(nil), []int(nil), []float64(nil), struct{}{},
+		map[string]interface{}(nil), []interface{}(nil),
+	} {
+		RegisterType(prototype)
+	}
+}
+
+// RegisterType registers the type of the given prototype so that recorded values of this
+// type, or pointers to it, can be decoded when replaying. Like gob.Register, it should be
+// called in an init function for every type that components produce or load. Basic types
+// are registered by default.
+func RegisterType(prototype interface{}) {
+	t := reflect.TypeOf(prototype)
+
+	types.Lock()
+	defer types.Unlock()
+
+	types.byName[typeName(t)] = t
+}
+
+func typeName(t reflect.Type) string {
+	if t.Kind() == reflect.Pointer {
+		return "*" + typeName(t.Elem())
+	}
+
+	if t.Name() == "" || t.PkgPath() == "" {
+		return t.String()
+	}
+
+	return t.PkgPath() + "." + t.Name()
+}
+
+func findType(name string) (reflect.Type, error) {
+	if strings.HasPrefix(name, "*") {
+		elem, err := findType(name[1:])
+		if err != nil {
+			return nil, err
+		}
+
+		return reflect.PointerTo(elem), nil
+	}
+
+	types.RLock()
+	defer types.RUnlock()
+
+	t, ok := types.byName[name]
+	if !ok {
+		return nil, fmt.Errorf("type %v is not registered, call replay.RegisterType with a value of this type", name)
+	}
+
+	return t, nil
+}
+
+func encodeValue(v interface{}) Value {
+	if v == nil {
+		return Value{}
+	}
+
+	result := Value{
+		Type: typeName(reflect.TypeOf(v)),
+	}
+
+	content, err := json.Marshal(v)
+	if err != nil {
+		result.Unserializable = err.Error()
+		return result
+	}
+
+	result.Value = content
+
+	return result
+}
+
+func encodeValues(values map[string]interface{}) map[string]Value {
+	result := make(map[string]Value, len(values))
+	for k, v := range values {
+		result[k] = encodeValue(v)
+	}
+
+	return result
+}
+
+// Decode returns the value in its original type.
+func (v Value) Decode() (interface{}, error) {
+	if v.Type == "" {
+		return nil, nil
+	}
+
+	if v.Unserializable != "" {
+		return nil, fmt.Errorf("value of type %v was not recorded: %v", v.Type, v.Unserializable)
+	}
+
+	t, err := findType(v.Type)
+	if err != nil {
+		return nil, err
+	}
+
+	result := reflect.New(t)
+	if err := json.Unmarshal(v.Value, result.Interface()); err != nil {
+		return nil, err
+	}
+
+	return result.Elem().Interface(), nil
+}
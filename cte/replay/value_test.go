@@ -0,0 +1,64 @@
+package replay
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jamestrandung/go-cte-117/cte"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValue(t *testing.T) {
+	scenarios := []struct {
+		desc  string
+		value interface{}
+	}{
+		{
+			desc:  "nil",
+			value: nil,
+		},
+		{
+			desc:  "basic type",
+			value: 1.5,
+		},
+		{
+			desc:  "registered type",
+			value: route{Distance: 2},
+		},
+		{
+			desc:  "pointer to registered type",
+			value: &route{Distance: 2},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		sc := scenario
+
+		t.Run(
+			sc.desc, func(t *testing.T) {
+				decoded, err := encodeValue(sc.value).Decode()
+				assert.Nil(t, err)
+				assert.Equal(t, sc.value, decoded)
+			},
+		)
+	}
+}
+
+func TestValue_Errors(t *testing.T) {
+	type unregistered struct{}
+
+	_, err := encodeValue(unregistered{}).Decode()
+	assert.Error(t, err)
+
+	v := encodeValue(func() {})
+	assert.NotEmpty(t, v.Unserializable)
+
+	_, err = v.Decode()
+	assert.Error(t, err)
+}
+
+func TestRestoreError(t *testing.T) {
+	assert.Nil(t, restoreError(""))
+	assert.Equal(t, cte.ErrPlanExecutionEndingEarly, restoreError(cte.ErrPlanExecutionEndingEarly.Error()))
+	assert.Equal(t, errors.New("something"), restoreError("something"))
+}
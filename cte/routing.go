@@ -0,0 +1,24 @@
+package cte
+
+import (
+	"context"
+)
+
+type engineKey struct{}
+
+// ContextWithEngine returns a new context which makes every Engine executing a master plan
+// with it delegate the execution to the given Engine instead. This allows tests to execute
+// plans hardwired to a global Engine using an isolated one.
+//
+// Note: The override is honoured by every call to Engine.ExecuteMasterPlan and
+// Engine.ExecuteMasterPlanAsync, production code included, and is inherited by all nested
+// executions. A context carrying an Engine must therefore never reach code that is not
+// meant to execute on it.
+func ContextWithEngine(ctx context.Context, e Engine) context.Context {
+	return context.WithValue(ctx, engineKey{}, e)
+}
+
+func extractEngine(ctx context.Context) (Engine, bool) {
+	e, ok := ctx.Value(engineKey{}).(Engine)
+	return e, ok
+}
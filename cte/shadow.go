@@ -0,0 +1,163 @@
+package cte
+
+import (
+	"context"
+	"reflect"
+	"time"
+)
+
+// ShadowReport describes how the shadow of a master plan diverged from the primary execution.
+type ShadowReport struct {
+	PlanName   string
+	Mismatches []ShadowMismatch
+	PrimaryErr error
+	ShadowErr  error
+}
+
+// ShadowMismatch describes a getter returning different values for a master plan and its shadow.
+type ShadowMismatch struct {
+	Getter  string
+	Primary interface{}
+	Shadow  interface{}
+}
+
+type shadowConfigs struct {
+	// build returns the shadow of the given plan. If nil, the shadow is a copy of the plan
+	// executed by engine.
+	build func(p MasterPlan) MasterPlan
+	// key and computer are substituted in engine when shadowing a computer
+	key      MetadataProvider
+	computer interface{}
+	engine   *Engine
+	getters  []string
+	handler  func(ShadowReport)
+}
+
+// newShadowEngines creates the engines that shadow computers execute with. They are configured
+// with the same options as the primary engine on top of the substituted computer.
+func newShadowEngines(configs *engineConfigs) {
+	for planName, sc := range configs.shadows {
+		if sc.key == nil {
+			continue
+		}
+
+		options := make([]EngineOption, 0, len(configs.options)+2)
+		options = append(options, configs.options...)
+		options = append(options, WithComputerSubstitute(sc.key, sc.computer), asShadow())
+
+		se := NewEngine(options...)
+		sc.engine = &se
+
+		configs.shadows[planName] = sc
+	}
+}
+
+// asShadow prevents a shadow engine from having shadows of its own, writing to the cache of the
+// primary engine or reporting to the handlers of the primary engine.
+func asShadow() EngineOption {
+	return func(configs *engineConfigs) {
+		configs.shadows = nil
+		configs.budgetOverrunHandler = nil
+		configs.cancellationHandler = nil
+
+		if configs.cacheStore != nil {
+			configs.cacheStore = readOnlyCacheStore{configs.cacheStore}
+		}
+	}
+}
+
+// readOnlyCacheStore serves the outcomes cached by the primary engine to a shadow engine
+// while discarding those computed by the shadow.
+type readOnlyCacheStore struct {
+	CacheStore
+}
+
+func (readOnlyCacheStore) Set(string, interface{}, time.Duration) {}
+
+// startShadow prepares the shadow of the given master plan before it executes and returns the
+// function to call with the outcome of the primary execution to run the shadow asynchronously.
+func (e Engine) startShadow(planName string, p MasterPlan) func(primaryErr error) {
+	sc, ok := e.configs.shadows[planName]
+	if !ok {
+		return func(error) {}
+	}
+
+	var shadow MasterPlan
+	if sc.build != nil {
+		shadow = sc.build(p)
+	} else {
+		shadow = copyPlan(p)
+	}
+
+	return func(primaryErr error) {
+		// Shadows count as in-flight executions so that Shutdown waits for them
+		shadowCtx, exit, err := e.lifecycle.enter(context.Background())
+		if err != nil {
+			return
+		}
+
+		if sc.engine != nil {
+			shadowCtx = ContextWithEngine(shadowCtx, *sc.engine)
+		}
+
+		go func() {
+			defer exit()
+
+			shadowErr := shadow.Execute(shadowCtx)
+
+			report := ShadowReport{
+				PlanName:   planName,
+				Mismatches: compareGetters(p, shadow, sc.getters),
+				PrimaryErr: primaryErr,
+				ShadowErr:  shadowErr,
+			}
+
+			if len(report.Mismatches) > 0 || (primaryErr == nil) != (shadowErr == nil) {
+				sc.handler(report)
+			}
+		}()
+	}
+}
+
+// copyPlan returns a shallow copy of the given plan. It must be taken before the plan executes
+// so that no component has been set yet.
+func copyPlan(p MasterPlan) MasterPlan {
+	original := reflect.ValueOf(p)
+
+	result := reflect.New(original.Elem().Type())
+	result.Elem().Set(original.Elem())
+
+	return result.Interface().(MasterPlan)
+}
+
+func compareGetters(primary MasterPlan, shadow MasterPlan, getters []string) []ShadowMismatch {
+	var result []ShadowMismatch
+	for _, getter := range getters {
+		primaryValue := callGetter(primary, getter)
+		shadowValue := callGetter(shadow, getter)
+
+		if !reflect.DeepEqual(primaryValue, shadowValue) {
+			result = append(
+				result, ShadowMismatch{
+					Getter:  getter,
+					Primary: primaryValue,
+					Shadow:  shadowValue,
+				},
+			)
+		}
+	}
+
+	return result
+}
+
+// callGetter returns the value of the given getter on the given plan, or the panic value
+// if the getter does not exist or panics.
+func callGetter(p MasterPlan, getter string) (result interface{}) {
+	defer func() {
+		if r := recover(); r != nil {
+			result = r
+		}
+	}()
+
+	return reflect.ValueOf(p).MethodByName(getter).Call(nil)[0].Interface()
+}
@@ -0,0 +1,272 @@
+package cte
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type shadowTest_OneComputer struct{}
+
+func (shadowTest_OneComputer) Compute(ctx context.Context, p MasterPlan) (interface{}, error) {
+	return 1, nil
+}
+
+type shadowTest_TwoComputer struct{}
+
+func (shadowTest_TwoComputer) Compute(ctx context.Context, p MasterPlan) (interface{}, error) {
+	return 2, nil
+}
+
+type shadowTest_Value SyncResult
+
+func (shadowTest_Value) CTEMetadata() interface{} {
+	return struct {
+		computer shadowTest_OneComputer
+	}{}
+}
+
+type shadowTest_OtherValue SyncResult
+
+func (shadowTest_OtherValue) CTEMetadata() interface{} {
+	return struct {
+		computer shadowTest_TwoComputer
+	}{}
+}
+
+type shadowTest_Plan struct {
+	engine Engine
+	Value  shadowTest_Value
+}
+
+func (*shadowTest_Plan) IsSequentialCTEPlan() bool {
+	return true
+}
+
+func (p *shadowTest_Plan) Execute(ctx context.Context) error {
+	return p.engine.ExecuteMasterPlan(ctx, p)
+}
+
+func (p *shadowTest_Plan) GetValue() interface{} {
+	return p.Value.Outcome
+}
+
+type shadowTest_OtherPlan struct {
+	engine Engine
+	Value  shadowTest_OtherValue
+}
+
+func (*shadowTest_OtherPlan) IsSequentialCTEPlan() bool {
+	return true
+}
+
+func (p *shadowTest_OtherPlan) Execute(ctx context.Context) error {
+	return p.engine.ExecuteMasterPlan(ctx, p)
+}
+
+func (p *shadowTest_OtherPlan) GetValue() interface{} {
+	return p.Value.Outcome
+}
+
+func TestEngine_Shadow(t *testing.T) {
+	scenarios := []struct {
+		desc    string
+		options func(reports chan ShadowReport) []EngineOption
+	}{
+		{
+			desc: "shadow computer",
+			options: func(reports chan ShadowReport) []EngineOption {
+				return []EngineOption{
+					WithShadowComputer(
+						&shadowTest_Plan{}, shadowTest_Value{}, shadowTest_TwoComputer{}, []string{"GetValue"}, func(report ShadowReport) {
+							reports <- report
+						},
+					),
+				}
+			},
+		},
+		{
+			desc: "shadow plan",
+			options: func(reports chan ShadowReport) []EngineOption {
+				return []EngineOption{
+					WithShadowPlan(
+						&shadowTest_Plan{}, func(p MasterPlan) MasterPlan {
+							return &shadowTest_OtherPlan{
+								engine: p.(*shadowTest_Plan).engine,
+							}
+						}, []string{"GetValue"}, func(report ShadowReport) {
+							reports <- report
+						},
+					),
+				}
+			},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		sc := scenario
+
+		t.Run(
+			sc.desc, func(t *testing.T) {
+				reports := make(chan ShadowReport, 1)
+
+				e := NewEngine(sc.options(reports)...)
+				e.AnalyzePlan(&shadowTest_Plan{})
+				e.AnalyzePlan(&shadowTest_OtherPlan{})
+
+				p := &shadowTest_Plan{
+					engine: e,
+				}
+
+				assert.Nil(t, e.ExecuteMasterPlan(context.Background(), p))
+				assert.Equal(t, 1, p.GetValue())
+
+				select {
+				case report := <-reports:
+					assert.Equal(t, extractFullNameFromValue(p), report.PlanName)
+					assert.Equal(t, []ShadowMismatch{{Getter: "GetValue", Primary: 1, Shadow: 2}}, report.Mismatches)
+					assert.Nil(t, report.PrimaryErr)
+					assert.Nil(t, report.ShadowErr)
+				case <-time.After(time.Second):
+					assert.Fail(t, "shadow mismatch was not reported")
+				}
+
+				// Shutdown waits for shadows
+				assert.Nil(t, e.Shutdown(context.Background()))
+			},
+		)
+	}
+}
+
+type shadowTest_RateComputer struct {
+	rate  int
+	inits *int32
+}
+
+func (c *shadowTest_RateComputer) Init(ctx context.Context) error {
+	atomic.AddInt32(c.inits, 1)
+	return nil
+}
+
+func (c *shadowTest_RateComputer) Compute(ctx context.Context, p MasterPlan) (interface{}, error) {
+	return c.rate, nil
+}
+
+type shadowTest_Rate SyncResult
+
+func (shadowTest_Rate) CTEMetadata() interface{} {
+	return struct {
+		computer shadowTest_RateComputer
+	}{}
+}
+
+type shadowTest_CachePolicy struct{}
+
+func (shadowTest_CachePolicy) CacheKey(p MasterPlan) string {
+	return "key"
+}
+
+func (shadowTest_CachePolicy) CacheTTL() time.Duration {
+	return time.Minute
+}
+
+type shadowTest_CachedValue SyncResult
+
+func (shadowTest_CachedValue) CTEMetadata() interface{} {
+	return struct {
+		computer shadowTest_OneComputer
+		cache    shadowTest_CachePolicy
+	}{}
+}
+
+type shadowTest_MirroredPlan struct {
+	engine Engine
+	Value  shadowTest_CachedValue
+	Rate   shadowTest_Rate
+}
+
+func (*shadowTest_MirroredPlan) IsSequentialCTEPlan() bool {
+	return true
+}
+
+func (p *shadowTest_MirroredPlan) Execute(ctx context.Context) error {
+	return p.engine.ExecuteMasterPlan(ctx, p)
+}
+
+func (p *shadowTest_MirroredPlan) GetValue() interface{} {
+	return p.Value.Outcome
+}
+
+func (p *shadowTest_MirroredPlan) GetRate() interface{} {
+	return p.Rate.Outcome
+}
+
+type shadowTest_CacheStore struct {
+	CacheStore
+	sets int32
+}
+
+func (s *shadowTest_CacheStore) Set(key string, value interface{}, ttl time.Duration) {
+	atomic.AddInt32(&s.sets, 1)
+	s.CacheStore.Set(key, value, ttl)
+}
+
+func TestEngine_Shadow_MirrorsPrimary(t *testing.T) {
+	var inits int32
+
+	reports := make(chan ShadowReport, 1)
+	store := &shadowTest_CacheStore{CacheStore: NewLRUCacheStore(10)}
+
+	e := NewEngine(
+		WithCacheStore(store),
+		WithShadowComputer(
+			&shadowTest_MirroredPlan{}, shadowTest_CachedValue{}, shadowTest_TwoComputer{}, []string{"GetValue", "GetRate"}, func(report ShadowReport) {
+				reports <- report
+			},
+		),
+	)
+
+	e.RegisterComputerFactory(
+		shadowTest_Rate{}, func() interface{} {
+			return &shadowTest_RateComputer{rate: 3, inits: &inits}
+		},
+	)
+
+	e.AnalyzePlan(&shadowTest_MirroredPlan{})
+
+	assert.Nil(t, e.Start(context.Background()))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&inits), "computers of the shadow engine must be initialized too")
+
+	for i := 0; i < 2; i++ {
+		p := &shadowTest_MirroredPlan{
+			engine: e,
+		}
+
+		assert.Nil(t, e.ExecuteMasterPlan(context.Background(), p))
+		assert.Equal(t, 1, p.GetValue())
+		assert.Equal(t, 3, p.GetRate())
+
+		select {
+		case report := <-reports:
+			// The substitute is neither served from nor written to the cache of the primary
+			assert.Equal(t, []ShadowMismatch{{Getter: "GetValue", Primary: 1, Shadow: 2}}, report.Mismatches)
+		case <-time.After(time.Second):
+			assert.Fail(t, "shadow mismatch was not reported")
+		}
+	}
+
+	assert.Nil(t, e.Shutdown(context.Background()))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&store.sets))
+}
+
+func TestCompareGetters(t *testing.T) {
+	primary := &shadowTest_Plan{Value: shadowTest_Value{Outcome: 1}}
+
+	assert.Nil(t, compareGetters(primary, &shadowTest_Plan{Value: shadowTest_Value{Outcome: 1}}, []string{"GetValue"}))
+
+	mismatches := compareGetters(primary, &shadowTest_OtherPlan{}, []string{"GetValue"})
+	assert.Equal(t, []ShadowMismatch{{Getter: "GetValue", Primary: 1, Shadow: nil}}, mismatches)
+}
@@ -0,0 +1,227 @@
+package cte
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"runtime/debug"
+	"sync"
+)
+
+// SpeculativeSwitchComputer can be implemented by any SwitchComputer whose decision depends on a
+// slow input, typically its own loader. Engine then starts executing every candidate plan as soon
+// as the component starts, before its data is loaded and Switch is called. Once Switch returns,
+// the candidate whose type matches the returned plan is kept and the others are cancelled. The
+// kept candidate becomes the outcome of the component in place of the plan returned by Switch.
+// If no candidate matches, the returned plan is executed as usual.
+//
+// Note: Candidates must be built from the same inputs as the plans returned by Switch since
+// the plans returned by Switch are discarded when a candidate matches. Discarded candidates
+// keep executing until they notice the cancellation of their context, side effect computers
+// included. Effects outside of the plan, e.g. calls to external services, cannot be undone
+// and hence, candidates must only contain components that are safe to execute speculatively.
+// Engine.Shutdown waits for discarded candidates to stop.
+type SpeculativeSwitchComputer interface {
+	Candidates(p MasterPlan) []MasterPlan
+}
+
+type speculation struct {
+	branches []*speculativeBranch
+}
+
+type speculativeBranch struct {
+	plan    MasterPlan
+	cancel  context.CancelFunc
+	eo      *EarlyOutcome
+	effects *speculativeEffects
+	done    chan struct{}
+	err     error
+}
+
+type speculativeEffectsKey struct{}
+
+// speculativeEffects holds back the effects of a speculative branch that are observable outside
+// of the branch until it is known whether the branch is kept.
+type speculativeEffects struct {
+	mu        sync.Mutex
+	parent    *speculativeEffects
+	pending   []func()
+	kept      bool
+	discarded bool
+}
+
+// WhenKept runs fn right away unless the given context belongs to a candidate plan started
+// speculatively for a SpeculativeSwitchComputer. In that case, fn runs once the candidate is
+// kept, or never if it is discarded. Interceptors and other code with effects outside of the
+// plan, e.g. recording executions, should use it so that discarded candidates are not observed.
+func WhenKept(ctx context.Context, fn func()) {
+	if se, ok := ctx.Value(speculativeEffectsKey{}).(*speculativeEffects); ok {
+		se.add(fn)
+		return
+	}
+
+	fn()
+}
+
+func (se *speculativeEffects) add(fn func()) {
+	se.mu.Lock()
+	defer se.mu.Unlock()
+
+	if se.discarded {
+		return
+	}
+
+	if se.kept {
+		se.run(fn)
+		return
+	}
+
+	se.pending = append(se.pending, fn)
+}
+
+// run hands the given effect over to the enclosing branch, if any, since a candidate
+// of a speculative branch is only kept if the branch itself is kept too.
+func (se *speculativeEffects) run(fn func()) {
+	if se.parent != nil {
+		se.parent.add(fn)
+		return
+	}
+
+	fn()
+}
+
+func (se *speculativeEffects) keep() {
+	se.mu.Lock()
+	defer se.mu.Unlock()
+
+	if se.discarded {
+		return
+	}
+
+	se.kept = true
+
+	for _, fn := range se.pending {
+		se.run(fn)
+	}
+
+	se.pending = nil
+}
+
+func (se *speculativeEffects) discard() {
+	se.mu.Lock()
+	defer se.mu.Unlock()
+
+	if se.kept {
+		return
+	}
+
+	se.discarded = true
+	se.pending = nil
+}
+
+// speculate starts executing the candidates of the given computer if it supports speculation.
+// It returns nil otherwise.
+func (e Engine) speculate(ctx context.Context, c registeredComputer, p MasterPlan) *speculation {
+	ssc, ok := c.instance.(SpeculativeSwitchComputer)
+	if !ok {
+		return nil
+	}
+
+	result := &speculation{}
+	for _, candidate := range ssc.Candidates(p) {
+		// A candidate exceeding the switch depth is left to fail when it gets chosen
+		switchCtx, err := e.enterSwitch(ctx, p, candidate)
+		if err != nil {
+			continue
+		}
+
+		parent, _ := ctx.Value(speculativeEffectsKey{}).(*speculativeEffects)
+		effects := &speculativeEffects{
+			parent: parent,
+		}
+
+		branchCtx, cancel := context.WithCancel(context.WithValue(switchCtx, speculativeEffectsKey{}, effects))
+		branchCtx, eo := CaptureEarlyOutcome(branchCtx)
+
+		branch := &speculativeBranch{
+			plan:    candidate,
+			cancel:  cancel,
+			eo:      eo,
+			effects: effects,
+			done:    make(chan struct{}),
+		}
+
+		// Branches count as in-flight executions so that Shutdown waits for them
+		exit := e.lifecycle.join()
+
+		go func() {
+			defer exit()
+			defer close(branch.done)
+
+			// Panics are recovered by the component on the normal path, e.g. for
+			// a plan that was never analyzed, and must not crash the application
+			defer func() {
+				if r := recover(); r != nil {
+					branch.err = fmt.Errorf("panic executing speculative branch: %v \n %s", r, debug.Stack())
+				}
+			}()
+
+			branch.err = branch.plan.Execute(branchCtx)
+		}()
+
+		result.branches = append(result.branches, branch)
+	}
+
+	return result
+}
+
+// take returns the branch executing a plan of the same type as the given plan and discards
+// all other branches.
+func (s *speculation) take(mp MasterPlan) (*speculativeBranch, bool) {
+	if s == nil {
+		return nil, false
+	}
+
+	var result *speculativeBranch
+	for _, b := range s.branches {
+		if result == nil && reflect.TypeOf(b.plan) == reflect.TypeOf(mp) {
+			result = b
+			result.effects.keep()
+
+			continue
+		}
+
+		b.effects.discard()
+		b.cancel()
+	}
+
+	return result, result != nil
+}
+
+// cancelAll cancels every branch that is still running and discards the ones that were
+// not kept.
+func (s *speculation) cancelAll() {
+	if s == nil {
+		return
+	}
+
+	for _, b := range s.branches {
+		b.effects.discard()
+		b.cancel()
+	}
+}
+
+// await blocks until the branch completes and returns its outcome like a switched plan would.
+func (b *speculativeBranch) await() (interface{}, error) {
+	<-b.done
+
+	if b.err != nil {
+		return b.plan, b.err
+	}
+
+	if value, ok := b.eo.Value(); ok {
+		return value, nil
+	}
+
+	return b.plan, nil
+}
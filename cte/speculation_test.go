@@ -0,0 +1,386 @@
+package cte
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type speculationTest_Signals struct {
+	chosenStarted chan struct{}
+	otherStarted  chan struct{}
+	otherStopped  chan error
+}
+
+type speculationTest_ChosenComputer struct{}
+
+func (speculationTest_ChosenComputer) Compute(ctx context.Context, p MasterPlan) (interface{}, error) {
+	close(p.(*speculationTest_ChosenPlan).signals.chosenStarted)
+	return "chosen", nil
+}
+
+type speculationTest_ChosenValue SyncResult
+
+func (speculationTest_ChosenValue) CTEMetadata() interface{} {
+	return struct {
+		computer speculationTest_ChosenComputer
+	}{}
+}
+
+type speculationTest_ChosenPlan struct {
+	engine  Engine
+	signals speculationTest_Signals
+	Value   speculationTest_ChosenValue
+}
+
+func (*speculationTest_ChosenPlan) IsSequentialCTEPlan() bool {
+	return true
+}
+
+func (p *speculationTest_ChosenPlan) Execute(ctx context.Context) error {
+	return p.engine.ExecuteMasterPlan(ctx, p)
+}
+
+type speculationTest_OtherComputer struct{}
+
+func (speculationTest_OtherComputer) Compute(ctx context.Context, p MasterPlan) (interface{}, error) {
+	signals := p.(*speculationTest_OtherPlan).signals
+	close(signals.otherStarted)
+
+	<-ctx.Done()
+
+	signals.otherStopped <- ctx.Err()
+	return nil, ctx.Err()
+}
+
+type speculationTest_OtherValue SyncResult
+
+func (speculationTest_OtherValue) CTEMetadata() interface{} {
+	return struct {
+		computer speculationTest_OtherComputer
+	}{}
+}
+
+type speculationTest_OtherPlan struct {
+	engine  Engine
+	signals speculationTest_Signals
+	Value   speculationTest_OtherValue
+}
+
+func (*speculationTest_OtherPlan) IsSequentialCTEPlan() bool {
+	return true
+}
+
+func (p *speculationTest_OtherPlan) Execute(ctx context.Context) error {
+	return p.engine.ExecuteMasterPlan(ctx, p)
+}
+
+type speculationTest_SwitchComputer struct{}
+
+// Load stands for a slow input that only becomes available after both branches started
+func (speculationTest_SwitchComputer) Load(ctx context.Context, p MasterPlan) (interface{}, error) {
+	signals := p.(*speculationTest_Plan).signals
+	timeout := time.After(time.Second)
+
+	for _, started := range []chan struct{}{signals.chosenStarted, signals.otherStarted} {
+		select {
+		case <-started:
+		case <-timeout:
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+func (speculationTest_SwitchComputer) Switch(ctx context.Context, p MasterPlan, data LoadingData) (MasterPlan, error) {
+	casted := p.(*speculationTest_Plan)
+
+	return &speculationTest_ChosenPlan{
+		engine:  casted.engine,
+		signals: casted.signals,
+	}, nil
+}
+
+func (speculationTest_SwitchComputer) Candidates(p MasterPlan) []MasterPlan {
+	casted := p.(*speculationTest_Plan)
+
+	return []MasterPlan{
+		&speculationTest_OtherPlan{
+			engine:  casted.engine,
+			signals: casted.signals,
+		},
+		&speculationTest_ChosenPlan{
+			engine:  casted.engine,
+			signals: casted.signals,
+		},
+	}
+}
+
+type speculationTest_Branch SyncResult
+
+func (speculationTest_Branch) CTEMetadata() interface{} {
+	return struct {
+		computer speculationTest_SwitchComputer
+	}{}
+}
+
+type speculationTest_Plan struct {
+	engine  Engine
+	signals speculationTest_Signals
+	Branch  speculationTest_Branch
+}
+
+func (*speculationTest_Plan) IsSequentialCTEPlan() bool {
+	return true
+}
+
+func (p *speculationTest_Plan) Execute(ctx context.Context) error {
+	return p.engine.ExecuteMasterPlan(ctx, p)
+}
+
+func TestEngine_SpeculativeSwitch(t *testing.T) {
+	e := NewEngine()
+	e.AnalyzePlan(&speculationTest_Plan{})
+	e.AnalyzePlan(&speculationTest_ChosenPlan{})
+	e.AnalyzePlan(&speculationTest_OtherPlan{})
+
+	p := &speculationTest_Plan{
+		engine: e,
+		signals: speculationTest_Signals{
+			chosenStarted: make(chan struct{}),
+			otherStarted:  make(chan struct{}),
+			otherStopped:  make(chan error, 1),
+		},
+	}
+
+	start := time.Now()
+	assert.Nil(t, e.ExecuteMasterPlan(context.Background(), p))
+
+	// The loader did not have to time out since the chosen branch started speculatively
+	assert.Less(t, int64(time.Since(start)), int64(time.Second))
+
+	chosen, ok := p.Branch.Outcome.(*speculationTest_ChosenPlan)
+	assert.True(t, ok)
+	assert.Equal(t, "chosen", chosen.Value.Outcome)
+
+	select {
+	case err := <-p.signals.otherStopped:
+		assert.Equal(t, context.Canceled, err)
+	case <-time.After(time.Second):
+		assert.Fail(t, "other branch was not cancelled")
+	}
+}
+
+func TestSpeculation_Take(t *testing.T) {
+	var nilSpec *speculation
+
+	_, ok := nilSpec.take(&speculationTest_ChosenPlan{})
+	assert.False(t, ok)
+	nilSpec.cancelAll()
+
+	cancelled := 0
+	cancel := func() {
+		cancelled++
+	}
+
+	s := &speculation{
+		branches: []*speculativeBranch{
+			{plan: &speculationTest_OtherPlan{}, cancel: cancel, effects: &speculativeEffects{}},
+			{plan: &speculationTest_ChosenPlan{}, cancel: cancel, effects: &speculativeEffects{}},
+		},
+	}
+
+	branch, ok := s.take(&speculationTest_ChosenPlan{})
+	assert.True(t, ok)
+	assert.Equal(t, s.branches[1], branch)
+	assert.Equal(t, 1, cancelled)
+	assert.True(t, s.branches[0].effects.discarded)
+	assert.True(t, s.branches[1].effects.kept)
+
+	_, ok = s.take(&speculationTest_Plan{})
+	assert.False(t, ok)
+	assert.Equal(t, 3, cancelled)
+}
+
+func TestWhenKept(t *testing.T) {
+	var effects []string
+	record := func(effect string) func() {
+		return func() {
+			effects = append(effects, effect)
+		}
+	}
+
+	WhenKept(context.Background(), record("outside"))
+	assert.Equal(t, []string{"outside"}, effects)
+
+	outer := &speculativeEffects{}
+	outerCtx := context.WithValue(context.Background(), speculativeEffectsKey{}, outer)
+
+	inner := &speculativeEffects{parent: outer}
+	innerCtx := context.WithValue(outerCtx, speculativeEffectsKey{}, inner)
+
+	discarded := &speculativeEffects{parent: outer}
+	discardedCtx := context.WithValue(outerCtx, speculativeEffectsKey{}, discarded)
+
+	WhenKept(outerCtx, record("outer"))
+	WhenKept(innerCtx, record("inner"))
+	WhenKept(discardedCtx, record("discarded"))
+
+	discarded.discard()
+	WhenKept(discardedCtx, record("discarded after discard"))
+
+	// Kept candidates of a branch that is not kept yet stay on hold
+	inner.keep()
+	assert.Equal(t, []string{"outside"}, effects)
+
+	outer.keep()
+	assert.Equal(t, []string{"outside", "outer", "inner"}, effects)
+
+	WhenKept(innerCtx, record("inner after keep"))
+	assert.Equal(t, []string{"outside", "outer", "inner", "inner after keep"}, effects)
+
+	// A kept branch is not discarded when cancelled afterwards
+	outer.discard()
+	WhenKept(outerCtx, record("outer after keep"))
+	assert.Equal(t, []string{"outside", "outer", "inner", "inner after keep", "outer after keep"}, effects)
+}
+
+func TestEngine_SpeculativeSwitch_DiscardedBranchIsNotObserved(t *testing.T) {
+	e := NewEngine()
+	e.AnalyzePlan(&speculationTest_Plan{})
+	e.AnalyzePlan(&speculationTest_ChosenPlan{})
+	e.AnalyzePlan(&speculationTest_OtherPlan{})
+
+	var mu sync.Mutex
+	var completed []string
+
+	ctx := ContextWithCompletionHandler(
+		context.Background(), func(c ComponentCompletion) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			completed = append(completed, c.ID)
+		},
+	)
+
+	p := &speculationTest_Plan{
+		engine: e,
+		signals: speculationTest_Signals{
+			chosenStarted: make(chan struct{}),
+			otherStarted:  make(chan struct{}),
+			otherStopped:  make(chan error, 1),
+		},
+	}
+
+	h := e.ExecuteMasterPlanAsync(ctx, p)
+	assert.Nil(t, h.Wait())
+
+	// The discarded branch started before it was discarded but must not show up
+	<-p.signals.otherStopped
+
+	chosenID := extractFullNameFromValue(speculationTest_ChosenValue{})
+	branchID := extractFullNameFromValue(speculationTest_Branch{})
+
+	assert.Equal(
+		t, []ComponentStatus{
+			{ID: branchID, State: ComponentSucceeded},
+			{ID: chosenID, State: ComponentSucceeded},
+		}, h.Status(),
+	)
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	assert.Equal(t, []string{chosenID, branchID}, completed)
+}
+
+func TestEngine_SpeculativeSwitch_ShutdownWaitsForDiscardedBranch(t *testing.T) {
+	e := NewEngine()
+	e.AnalyzePlan(&speculationTest_Plan{})
+	e.AnalyzePlan(&speculationTest_ChosenPlan{})
+	e.AnalyzePlan(&speculationTest_OtherPlan{})
+
+	p := &speculationTest_Plan{
+		engine: e,
+		signals: speculationTest_Signals{
+			chosenStarted: make(chan struct{}),
+			otherStarted:  make(chan struct{}),
+			// Unbuffered to hold the discarded branch until the test lets it stop
+			otherStopped: make(chan error),
+		},
+	}
+
+	assert.Nil(t, e.ExecuteMasterPlan(context.Background(), p))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	assert.Equal(t, context.DeadlineExceeded, e.Shutdown(ctx))
+
+	assert.Equal(t, context.Canceled, <-p.signals.otherStopped)
+	assert.Nil(t, e.Shutdown(context.Background()))
+}
+
+type speculationTest_UnanalyzedPlan struct {
+	engine Engine
+	Value  speculationTest_ChosenValue
+}
+
+func (*speculationTest_UnanalyzedPlan) IsSequentialCTEPlan() bool {
+	return true
+}
+
+func (p *speculationTest_UnanalyzedPlan) Execute(ctx context.Context) error {
+	return p.engine.ExecuteMasterPlan(ctx, p)
+}
+
+type speculationTest_PanickingSwitchComputer struct{}
+
+func (speculationTest_PanickingSwitchComputer) Switch(ctx context.Context, p MasterPlan) (MasterPlan, error) {
+	return &speculationTest_UnanalyzedPlan{
+		engine: p.(*speculationTest_PanickingPlan).engine,
+	}, nil
+}
+
+func (speculationTest_PanickingSwitchComputer) Candidates(p MasterPlan) []MasterPlan {
+	return []MasterPlan{
+		&speculationTest_UnanalyzedPlan{
+			engine: p.(*speculationTest_PanickingPlan).engine,
+		},
+	}
+}
+
+type speculationTest_PanickingBranch SyncResult
+
+func (speculationTest_PanickingBranch) CTEMetadata() interface{} {
+	return struct {
+		computer speculationTest_PanickingSwitchComputer
+	}{}
+}
+
+type speculationTest_PanickingPlan struct {
+	engine Engine
+	Branch speculationTest_PanickingBranch
+}
+
+func (*speculationTest_PanickingPlan) IsSequentialCTEPlan() bool {
+	return true
+}
+
+func (p *speculationTest_PanickingPlan) Execute(ctx context.Context) error {
+	return p.engine.ExecuteMasterPlan(ctx, p)
+}
+
+func TestEngine_SpeculativeSwitch_PanickingCandidate(t *testing.T) {
+	e := NewEngine()
+	e.AnalyzePlan(&speculationTest_PanickingPlan{})
+
+	// The candidate was never analyzed and hence, panics in its own goroutine
+	err := e.ExecuteMasterPlan(context.Background(), &speculationTest_PanickingPlan{engine: e})
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), ErrPlanNotAnalyzed.Err(extractFullNameFromValue(&speculationTest_UnanalyzedPlan{})).Error())
+}
@@ -0,0 +1,123 @@
+package cte
+
+import (
+	"context"
+	"sort"
+	"strings"
+)
+
+// defaultMaxSwitchDepth is the number of nested switches Engine allows in one execution unless
+// configured otherwise via WithMaxSwitchDepth.
+const defaultMaxSwitchDepth = 32
+
+type switchChainKey struct{}
+
+type switchChain struct {
+	plans []string
+	depth int
+}
+
+// enterSwitch returns a context recording that the given switched plan is executed on behalf of
+// the given plan. It returns a SwitchDepthError if doing so exceeds the configured depth.
+func (e Engine) enterSwitch(ctx context.Context, from MasterPlan, to MasterPlan) (context.Context, error) {
+	fromName := extractFullNameFromValue(from)
+	toName := extractFullNameFromValue(to)
+
+	cur, _ := ctx.Value(switchChainKey{}).(switchChain)
+
+	// Copy to prevent sibling switches from sharing the same backing array
+	plans := make([]string, 0, len(cur.plans)+2)
+	plans = append(plans, cur.plans...)
+
+	if len(plans) == 0 || plans[len(plans)-1] != fromName {
+		plans = append(plans, fromName)
+	}
+
+	next := switchChain{
+		plans: append(plans, toName),
+		depth: cur.depth + 1,
+	}
+
+	if limit := e.configs.switchDepthLimit(); next.depth > limit {
+		return ctx, SwitchDepthError{
+			Chain:    next.plans,
+			MaxDepth: limit,
+		}
+	}
+
+	return context.WithValue(ctx, switchChainKey{}, next), nil
+}
+
+// detectSwitchCycles returns ErrSwitchCycle if a plan can end up switching back into itself
+// via the plans declared under the `switchTargets` key of its components or nested plans.
+func (e Engine) detectSwitchCycles() error {
+	edges := make(map[string][]string, len(e.plans))
+	for planName, ap := range e.plans {
+		for _, component := range ap.components {
+			if _, ok := e.plans[component.id]; ok {
+				edges[planName] = append(edges[planName], component.id)
+				continue
+			}
+
+			if c, ok := e.computers[component.id]; ok {
+				edges[planName] = append(edges[planName], c.metadata.getSwitchTargets()...)
+			}
+		}
+	}
+
+	planNames := make([]string, 0, len(edges))
+	for planName := range edges {
+		planNames = append(planNames, planName)
+	}
+
+	sort.Strings(planNames)
+
+	const (
+		visiting = 1
+		visited  = 2
+	)
+
+	states := make(map[string]int, len(edges))
+
+	var path []string
+	var visit func(planName string) []string
+	visit = func(planName string) []string {
+		switch states[planName] {
+		case visiting:
+			for idx, p := range path {
+				if p == planName {
+					return append(append([]string{}, path[idx:]...), planName)
+				}
+			}
+		case visited:
+			return nil
+		}
+
+		states[planName] = visiting
+		path = append(path, planName)
+
+		for _, next := range edges[planName] {
+			if cycle := visit(next); cycle != nil {
+				return cycle
+			}
+		}
+
+		path = path[:len(path)-1]
+		states[planName] = visited
+
+		return nil
+	}
+
+	for _, planName := range planNames {
+		if cycle := visit(planName); cycle != nil {
+			shortNames := make([]string, 0, len(cycle))
+			for _, p := range cycle {
+				shortNames = append(shortNames, extractShortName(p))
+			}
+
+			return ErrSwitchCycle.Err(strings.Join(shortNames, " -> "))
+		}
+	}
+
+	return nil
+}
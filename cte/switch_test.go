@@ -0,0 +1,151 @@
+package cte
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type switchTest_LoopComputer struct{}
+
+func (switchTest_LoopComputer) Switch(ctx context.Context, p MasterPlan) (MasterPlan, error) {
+	return &switchTest_LoopPlan{
+		engine: p.(*switchTest_LoopPlan).engine,
+	}, nil
+}
+
+type switchTest_Loop SyncResult
+
+func (switchTest_Loop) CTEMetadata() interface{} {
+	return struct {
+		computer      switchTest_LoopComputer
+		switchTargets struct {
+			loop *switchTest_LoopPlan
+		}
+	}{}
+}
+
+type switchTest_LoopPlan struct {
+	engine Engine
+	Loop   switchTest_Loop
+}
+
+func (*switchTest_LoopPlan) IsSequentialCTEPlan() bool {
+	return true
+}
+
+func (p *switchTest_LoopPlan) Execute(ctx context.Context) error {
+	return p.engine.ExecuteMasterPlan(ctx, p)
+}
+
+type switchTest_EndComputer struct{}
+
+func (switchTest_EndComputer) Compute(ctx context.Context, p MasterPlan) (interface{}, error) {
+	return 1, nil
+}
+
+type switchTest_End SyncResult
+
+func (switchTest_End) CTEMetadata() interface{} {
+	return struct {
+		computer switchTest_EndComputer
+	}{}
+}
+
+type switchTest_EndPlan struct {
+	End switchTest_End
+}
+
+func (*switchTest_EndPlan) IsSequentialCTEPlan() bool {
+	return true
+}
+
+type switchTest_InvalidTargets SyncResult
+
+func (switchTest_InvalidTargets) CTEMetadata() interface{} {
+	return struct {
+		computer      switchTest_LoopComputer
+		switchTargets *switchTest_EndPlan
+	}{}
+}
+
+type switchTest_InvalidTargetsPlan struct {
+	Invalid switchTest_InvalidTargets
+}
+
+func (*switchTest_InvalidTargetsPlan) IsSequentialCTEPlan() bool {
+	return true
+}
+
+func TestEngine_ExecuteMasterPlan_SwitchDepth(t *testing.T) {
+	scenarios := []struct {
+		desc          string
+		options       []EngineOption
+		expectedDepth int
+	}{
+		{
+			desc:          "default depth",
+			expectedDepth: defaultMaxSwitchDepth,
+		},
+		{
+			desc:          "configured depth",
+			options:       []EngineOption{WithMaxSwitchDepth(3)},
+			expectedDepth: 3,
+		},
+	}
+
+	for _, scenario := range scenarios {
+		sc := scenario
+		t.Run(
+			sc.desc, func(t *testing.T) {
+				e := NewEngine(sc.options...)
+				e.AnalyzePlan(&switchTest_LoopPlan{})
+
+				err := e.ExecuteMasterPlan(context.Background(), &switchTest_LoopPlan{engine: e})
+
+				var sde SwitchDepthError
+				assert.True(t, errors.As(err, &sde))
+				assert.Equal(t, sc.expectedDepth, sde.MaxDepth)
+
+				// The first plan contains the switch, every following one was switched into
+				assert.Equal(t, sc.expectedDepth+2, len(sde.Chain))
+				for _, planName := range sde.Chain {
+					assert.Equal(t, extractFullNameFromValue(&switchTest_LoopPlan{}), planName)
+				}
+			},
+		)
+	}
+}
+
+func TestEngine_DetectSwitchCycles(t *testing.T) {
+	e := NewEngine()
+	e.AnalyzePlan(&switchTest_EndPlan{})
+
+	assert.Nil(t, e.detectSwitchCycles())
+
+	e.AnalyzePlan(&switchTest_LoopPlan{})
+
+	assert.Equal(
+		t,
+		ErrSwitchCycle.Err("switchTest_LoopPlan -> switchTest_LoopPlan"),
+		e.detectSwitchCycles(),
+	)
+}
+
+func TestValidateMetaField_SwitchTargets(t *testing.T) {
+	e := NewEngine()
+
+	assert.PanicsWithError(
+		t,
+		ErrInvalidSwitchTargets.Err(
+			reflect.TypeOf(&switchTest_EndPlan{}),
+			reflect.TypeOf(&switchTest_InvalidTargets{}),
+		).Error(),
+		func() {
+			e.AnalyzePlan(&switchTest_InvalidTargetsPlan{})
+		},
+	)
+}
@@ -1,19 +1,40 @@
 package cte
 
 import (
+	"errors"
 	"reflect"
 	"strings"
 )
 
 func swallowErrPlanExecutionEndingEarly(err error) error {
 	// Execution was intentionally ended by clients
-	if err == ErrPlanExecutionEndingEarly || err == ErrRootPlanExecutionEndingEarly {
+	if isEndingEarly(err) {
 		return nil
 	}
 
 	return err
 }
 
+func isEndingEarly(err error) bool {
+	return errors.Is(err, ErrPlanExecutionEndingEarly) || errors.Is(err, ErrRootPlanExecutionEndingEarly)
+}
+
+// swallowOptionalComponentErr returns nil if the given error was produced by an optional
+// component. Errors intentionally thrown to end execution early and loader errors that
+// must fail the plan are always kept.
+func swallowOptionalComponentErr(err error, isOptional bool) error {
+	if err == nil || !isOptional || isEndingEarly(err) {
+		return err
+	}
+
+	var lf loaderFailure
+	if errors.As(err, &lf) {
+		return err
+	}
+
+	return nil
+}
+
 func extractFullNameFromValue(v interface{}) string {
 	return extractFullNameFromType(reflect.TypeOf(v))
 }
@@ -32,6 +32,11 @@ func TestSwallowErrPlanExecutionEndingEarly(t *testing.T) {
 			err:      ErrRootPlanExecutionEndingEarly,
 			expected: nil,
 		},
+		{
+			desc:     "EndEarlyWith",
+			err:      EndEarlyWith(1),
+			expected: nil,
+		},
 		{
 			desc:     "other errors",
 			err:      assert.AnError,
@@ -227,3 +232,14 @@ func TestExtractNonPointerType(t *testing.T) {
 		)
 	}
 }
+
+func TestSwallowOptionalComponentErr(t *testing.T) {
+	assert.Nil(t, swallowOptionalComponentErr(nil, true))
+	assert.Nil(t, swallowOptionalComponentErr(assert.AnError, true))
+	assert.Equal(t, assert.AnError, swallowOptionalComponentErr(assert.AnError, false))
+	assert.Equal(t, ErrPlanExecutionEndingEarly, swallowOptionalComponentErr(ErrPlanExecutionEndingEarly, true))
+	assert.Equal(t, ErrRootPlanExecutionEndingEarly, swallowOptionalComponentErr(ErrRootPlanExecutionEndingEarly, true))
+
+	lf := loaderFailure{componentID: "component", err: assert.AnError}
+	assert.Equal(t, lf, swallowOptionalComponentErr(lf, true))
+}
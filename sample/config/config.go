@@ -7,7 +7,10 @@ import (
 )
 
 var Engine = &CostEngine{
-	Engine: cte.NewEngine(),
+	Engine: cte.NewEngine(
+		cte.WithDeadlineAwareSkipping(0.95),
+		cte.WithCacheStore(cte.NewLRUCacheStore(1024)),
+	),
 }
 
 var printDebugLog = false
@@ -2,6 +2,7 @@ package costconfigs
 
 import (
 	"context"
+	"time"
 
 	"github.com/jamestrandung/go-cte-117/cte"
 
@@ -19,3 +20,14 @@ func (c computer) Compute(ctx context.Context, p cte.MasterPlan) (interface{}, e
 func (c computer) doFetch(p inout) configsfetcher.MergedCostConfigs {
 	return p.GetConfigsFetcher().Fetch()
 }
+
+type cachePolicy struct{}
+
+// Merged configs are the same for every quote, no need to fetch them again and again
+func (cachePolicy) CacheKey(p cte.MasterPlan) string {
+	return "merged"
+}
+
+func (cachePolicy) CacheTTL() time.Duration {
+	return time.Minute
+}
@@ -19,6 +19,7 @@ func (c CostConfigs) CTEMetadata() interface{} {
 	return struct {
 		computer computer
 		inout    inout
+		cache    cachePolicy
 	}{}
 }
 
@@ -25,8 +25,12 @@ type FixedCostBranch cte.Result
 
 func (c FixedCostBranch) CTEMetadata() interface{} {
 	return struct {
-		computer computer
-		inout    inout
+		computer      computer
+		inout         inout
+		switchTargets struct {
+			fixedCost   *fixedcost.SequentialPlan
+			calculation *calculation.SequentialPlan
+		}
 	}{}
 }
 
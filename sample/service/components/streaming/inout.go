@@ -16,5 +16,6 @@ func (c CostStreaming) CTEMetadata() interface{} {
 	return struct {
 		computer computer
 		inout    inout
+		optional cte.Optional
 	}{}
 }
@@ -2,6 +2,8 @@ package endpoint
 
 import (
 	"context"
+	"time"
+
 	"github.com/jamestrandung/go-cte-117/sample/config"
 	"github.com/jamestrandung/go-cte-117/sample/service/components/quote"
 	"github.com/jamestrandung/go-cte-117/sample/service/components/streaming"
@@ -27,6 +29,10 @@ func (p *SequentialPlan) IsSequentialCTEPlan() bool {
 	return true
 }
 
+func (p *SequentialPlan) CTEBudget() time.Duration {
+	return 200 * time.Millisecond
+}
+
 func (p *SequentialPlan) Execute(ctx context.Context) error {
 	return config.Engine.ExecuteMasterPlan(ctx, p)
 }
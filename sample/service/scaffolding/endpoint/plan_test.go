@@ -0,0 +1,34 @@
+package endpoint
+
+import (
+	"testing"
+
+	"github.com/jamestrandung/go-cte-117/cte"
+	"github.com/jamestrandung/go-cte-117/cte/ctetest"
+	"github.com/jamestrandung/go-cte-117/sample/dependencies/configsfetcher"
+	"github.com/jamestrandung/go-cte-117/sample/dependencies/mapservice"
+	"github.com/jamestrandung/go-cte-117/sample/dto"
+	"github.com/jamestrandung/go-cte-117/sample/service/components/costconfigs"
+	"github.com/jamestrandung/go-cte-117/sample/service/components/travelplan"
+	"github.com/jamestrandung/go-cte-117/sample/service/scaffolding/calculation"
+	"github.com/jamestrandung/go-cte-117/sample/service/scaffolding/fixedcost"
+)
+
+func TestSequentialPlan_Cases(t *testing.T) {
+	ctetest.RunCases(
+		t, "testdata/cases.yaml", func(input ctetest.Values) (cte.MasterPlan, error) {
+			var request dto.CostRequest
+			if err := input.Decode(&request); err != nil {
+				return nil, err
+			}
+
+			// Dependencies are never used since loading components are stubbed
+			return NewPlan(request, nil), nil
+		},
+		ctetest.WithStubbable(costconfigs.CostConfigs{}, configsfetcher.MergedCostConfigs{}),
+		ctetest.WithStubbable(travelplan.TravelPlan{}, mapservice.Route{}),
+		ctetest.WithHarnessOptions(
+			ctetest.WithPlans(&calculation.SequentialPlan{}, &fixedcost.SequentialPlan{}),
+		),
+	)
+}
@@ -1,6 +1,8 @@
 package loading
 
 import (
+	"time"
+
 	"github.com/jamestrandung/go-cte-117/sample/service/components/costconfigs"
 	"github.com/jamestrandung/go-cte-117/sample/service/components/travelcost"
 	"github.com/jamestrandung/go-cte-117/sample/service/components/travelplan"
@@ -15,3 +17,7 @@ type ParallelPlan struct {
 func (p *ParallelPlan) IsSequentialCTEPlan() bool {
 	return false
 }
+
+func (p *ParallelPlan) CTEBudget() time.Duration {
+	return 80 * time.Millisecond
+}